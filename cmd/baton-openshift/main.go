@@ -3,8 +3,13 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"slices"
+	"time"
 
+	"github.com/conductorone/baton-openshift/pkg/client"
 	"github.com/conductorone/baton-openshift/pkg/config"
 	"github.com/conductorone/baton-openshift/pkg/connector"
 	configSchema "github.com/conductorone/baton-sdk/pkg/config"
@@ -50,23 +55,46 @@ func getConnector(ctx context.Context, cfg *config.Openshift) (types.ConnectorSe
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	var restConfig *rest.Config
-	var err error
-	kubeConfigPath := cfg.KubeConfig
-	if kubeConfigPath == "" {
-		l.Debug("no kubeconfig file specified. trying in-cluster config")
-		restConfig, err = rest.InClusterConfig()
+	var clusters []connector.Cluster
+	if len(cfg.Clusters) == 0 {
+		restConfig, err := restConfigFor(ctx, cfg, "")
 		if err != nil {
-			return nil, fmt.Errorf("unable to build configuration from in-cluster config, error: %w", err)
+			return nil, err
 		}
+		clusters = append(clusters, connector.Cluster{Config: restConfig})
 	} else {
-		restConfig, err = clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+		contexts, err := config.ParseClusters(cfg)
 		if err != nil {
-			return nil, fmt.Errorf("unable to build configuration from kubeconfig file, error: %w", err)
+			return nil, err
+		}
+		// a cluster that can't be configured doesn't stop the others
+		for _, cc := range contexts {
+			restConfig, err := restConfigFor(ctx, cfg, cc.Context)
+			clusters = append(clusters, connector.Cluster{Name: cc.Name, Config: restConfig, Err: err})
 		}
 	}
 
-	cb, err := connector.New(ctx, cfg.Namespace, restConfig)
+	namespaces := cfg.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{cfg.Namespace}
+	}
+	filters, err := newFilters(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	cb, err := connector.New(
+		ctx,
+		clusters,
+		client.WithNamespaces(namespaces...),
+		client.WithConcurrency(cfg.Concurrency),
+		client.WithPageSize(cfg.PageSize),
+		client.WithIndexTTL(time.Duration(cfg.IndexTtl)*time.Second),
+		client.WithInformerCache(cfg.InformerCache),
+		client.WithFilters(filters),
+		client.WithPermissionEntitlements(cfg.PermissionEntitlements),
+		client.WithLastLogin(cfg.SyncLastLogin),
+	)
 	if err != nil {
 		l.Error("error creating connector", zap.Error(err))
 		return nil, err
@@ -79,3 +107,139 @@ func getConnector(ctx context.Context, cfg *config.Openshift) (types.ConnectorSe
 	}
 	return connectorServer, nil
 }
+
+// restConfigFor builds the configuration of the connection to the API
+// server, for the given context of the kubeconfig (if any).
+func restConfigFor(ctx context.Context, cfg *config.Openshift, contextName string) (*rest.Config, error) {
+	l := ctxzap.Extract(ctx)
+
+	var restConfig *rest.Config
+	var err error
+	switch {
+	case cfg.ApiServerUrl != "":
+		l.Debug("using the API server URL from the configuration")
+		restConfig = &rest.Config{
+			Host:        cfg.ApiServerUrl,
+			BearerToken: cfg.BearerToken,
+			TLSClientConfig: rest.TLSClientConfig{
+				CAData: []byte(cfg.CaCert),
+			},
+		}
+	case cfg.KubeConfig == "" && cfg.KubeConfigContent == "":
+		l.Debug("no kubeconfig file specified. trying in-cluster config")
+		restConfig, err = rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("unable to build configuration from in-cluster config, error: %w", err)
+		}
+	default:
+		kubeConfig, err := config.LoadKubeConfig(cfg, contextName)
+		if err != nil {
+			return nil, err
+		}
+		l.Debug("using kubeconfig", zap.String("context", kubeConfig.CurrentContext))
+		restConfig, err = clientcmd.NewDefaultClientConfig(*kubeConfig, &clientcmd.ConfigOverrides{}).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("unable to build configuration from kubeconfig, error: %w", err)
+		}
+	}
+
+	if err := applyTLSAndProxy(ctx, cfg, restConfig); err != nil {
+		return nil, err
+	}
+	restConfig.QPS = float32(cfg.Qps)
+	restConfig.Burst = cfg.Burst
+	restConfig.Timeout = time.Duration(cfg.RequestTimeout) * time.Second
+
+	if cfg.Username != "" {
+		l.Debug("logging in the OpenShift OAuth server", zap.String("username", cfg.Username))
+		// only the CAs given by the user are trusted for the OAuth
+		// server, the kubeconfig ones sign the API server certificate
+		caFile, caData := cfg.TlsCaBundlePath, []byte(nil)
+		if cfg.TlsCaBundle != "" {
+			caFile, caData = "", []byte(cfg.TlsCaBundle)
+		}
+		restConfig, err = client.WithOAuthLogin(ctx, restConfig, cfg.Username, cfg.Password, caFile, caData)
+		if err != nil {
+			return nil, fmt.Errorf("unable to log in the OpenShift OAuth server, error: %w", err)
+		}
+	}
+
+	// set after the login, the configuration it returns drops it
+	if cfg.ImpersonateUser != "" {
+		l.Debug("impersonating", zap.String("user", cfg.ImpersonateUser), zap.Strings("groups", cfg.ImpersonateGroups))
+		restConfig.Impersonate = rest.ImpersonationConfig{
+			UserName: cfg.ImpersonateUser,
+			Groups:   cfg.ImpersonateGroups,
+		}
+	}
+
+	return restConfig, nil
+}
+
+// newFilters parses the include and exclude rules of the configuration,
+// adding the OpenShift defaults to the exclude rules if enabled.
+func newFilters(cfg *config.Openshift) (client.Filters, error) {
+	exclude := func(rules, defaults []string) []string {
+		if cfg.OpenshiftDefaultFilters {
+			return append(slices.Clone(rules), defaults...)
+		}
+		return rules
+	}
+
+	var filters client.Filters
+	var err error
+	if filters.Users, err = client.NewFilter(cfg.IncludeUsers, exclude(cfg.ExcludeUsers, client.OpenShiftExcludedUsers)); err != nil {
+		return filters, fmt.Errorf("users filter: %w", err)
+	}
+	if filters.Groups, err = client.NewFilter(cfg.IncludeGroups, exclude(cfg.ExcludeGroups, client.OpenShiftExcludedGroups)); err != nil {
+		return filters, fmt.Errorf("groups filter: %w", err)
+	}
+	if filters.Namespaces, err = client.NewFilter(cfg.IncludeNamespaces, exclude(cfg.ExcludeNamespaces, client.OpenShiftExcludedNamespaces)); err != nil {
+		return filters, fmt.Errorf("namespaces filter: %w", err)
+	}
+	if filters.Roles, err = client.NewFilter(cfg.IncludeRoles, exclude(cfg.ExcludeRoles, client.OpenShiftExcludedRoles)); err != nil {
+		return filters, fmt.Errorf("roles filter: %w", err)
+	}
+	if filters.ServiceAccounts, err = client.NewFilter(cfg.IncludeServiceAccounts, exclude(cfg.ExcludeServiceAccounts, client.OpenShiftExcludedServiceAccounts)); err != nil {
+		return filters, fmt.Errorf("service accounts filter: %w", err)
+	}
+
+	return filters, nil
+}
+
+// applyTLSAndProxy overrides the TLS settings and the proxy of the
+// configuration built from the kubeconfig, the in-cluster config or
+// the API server URL.
+func applyTLSAndProxy(ctx context.Context, cfg *config.Openshift, restConfig *rest.Config) error {
+	l := ctxzap.Extract(ctx)
+
+	if cfg.TlsCaBundlePath != "" {
+		restConfig.CAFile = cfg.TlsCaBundlePath
+		restConfig.CAData = nil
+	}
+	if cfg.TlsCaBundle != "" {
+		restConfig.CAFile = ""
+		restConfig.CAData = []byte(cfg.TlsCaBundle)
+	}
+	if cfg.TlsServerName != "" {
+		restConfig.ServerName = cfg.TlsServerName
+	}
+	if cfg.TlsInsecureSkipVerify {
+		l.Warn("!!! TLS VERIFICATION OF THE API SERVER IS DISABLED !!! " +
+			"the connection can be intercepted and the credentials stolen, never use tls-insecure-skip-verify in production")
+		restConfig.Insecure = true
+		// client-go refuses to skip the verification with a CA set
+		restConfig.CAFile = ""
+		restConfig.CAData = nil
+	}
+	if cfg.ProxyUrl != "" {
+		proxyURL, err := url.Parse(cfg.ProxyUrl)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL, error: %w", err)
+		}
+		l.Debug("using proxy", zap.String("proxy", proxyURL.Redacted()))
+		restConfig.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package client
+
+// aggregation.go resolves the aggregated cluster roles, e.g. `admin`,
+// `edit` and `view`, whose rules come from the cluster roles selected by
+// their aggregation rule. Operators add the permissions of their custom
+// resources this way, so holding `edit` may allow much more than the
+// rules of `edit` itself tell.
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// aggregation indexes the aggregation rules of the cluster roles, both
+// ways, with the cluster roles sorted by name.
+type aggregation struct {
+	// contributors maps the aggregated cluster roles to the cluster
+	// roles their rules come from.
+	contributors map[string][]rbacv1.ClusterRole
+	// aggregatedTo maps the cluster roles to the cluster roles that
+	// aggregate them.
+	aggregatedTo map[string][]rbacv1.ClusterRole
+}
+
+// aggregationIndex returns the aggregation index of the cluster roles,
+// it is reused until stale.
+func (c *Client) aggregationIndex(ctx context.Context) (*aggregation, error) {
+	return c.aggregation.get(c.staleAfter(), func() (*aggregation, error) {
+		all, err := listAll(ctx, c, c.listClusterRoles)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list cluster roles, error: %w", err)
+		}
+		sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+
+		index := &aggregation{
+			contributors: map[string][]rbacv1.ClusterRole{},
+			aggregatedTo: map[string][]rbacv1.ClusterRole{},
+		}
+		for _, role := range all {
+			if role.AggregationRule == nil {
+				continue
+			}
+			selectors, err := aggregationSelectors(role)
+			if err != nil {
+				return nil, err
+			}
+			for _, contributor := range all {
+				if contributor.Name == role.Name || !matchesAny(selectors, contributor.Labels) {
+					continue
+				}
+				index.contributors[role.Name] = append(index.contributors[role.Name], contributor)
+				index.aggregatedTo[contributor.Name] = append(index.aggregatedTo[contributor.Name], role)
+			}
+		}
+
+		return index, nil
+	})
+}
+
+// aggregationSelectors parses the selectors of the aggregation rule of
+// a cluster role, empty selectors select nothing.
+func aggregationSelectors(role rbacv1.ClusterRole) ([]labels.Selector, error) {
+	var selectors []labels.Selector
+	for _, selector := range role.AggregationRule.ClusterRoleSelectors {
+		sel, err := metav1.LabelSelectorAsSelector(&selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid aggregation rule of cluster role %s, error: %w", role.Name, err)
+		}
+		if !sel.Empty() {
+			selectors = append(selectors, sel)
+		}
+	}
+	return selectors, nil
+}
+
+func matchesAny(selectors []labels.Selector, lbls map[string]string) bool {
+	for _, sel := range selectors {
+		if sel.Matches(labels.Set(lbls)) {
+			return true
+		}
+	}
+	return false
+}
+
+// aggregationContributors maps the aggregated cluster roles among the
+// given ones to the cluster roles contributing their rules, sorted by
+// name. The cluster roles are only all listed if some are aggregated.
+func (c *Client) aggregationContributors(ctx context.Context, roles []rbacv1.ClusterRole) (map[string][]rbacv1.ClusterRole, error) {
+	contributors := map[string][]rbacv1.ClusterRole{}
+	for _, role := range roles {
+		if role.AggregationRule == nil {
+			continue
+		}
+		index, err := c.aggregationIndex(ctx)
+		if err != nil {
+			return nil, err
+		}
+		contributors[role.Name] = index.contributors[role.Name]
+	}
+
+	return contributors, nil
+}
+
+// AggregationGrants grants the membership of a cluster role to the
+// synced cluster roles that aggregate it, expanded to their members:
+// whoever holds `edit` holds every cluster role aggregated to `edit`.
+func (c *Client) AggregationGrants(ctx context.Context, entitlement *v2.Resource) ([]*v2.Grant, error) {
+	contributor, err := roleRefOf(entitlement)
+	if err != nil {
+		return nil, err
+	}
+	index, err := c.aggregationIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var gnts []*v2.Grant
+	for _, role := range filterObjects(c.filters.Roles, index.aggregatedTo[contributor.Name]) {
+		principal := &v2.Resource{Id: c.scopeID(&v2.ResourceId{ResourceType: clusterRoleResourceTypeID, Resource: string(role.UID)})}
+		gnts = append(gnts, grant.NewGrant(
+			entitlement,
+			"member",
+			principal.Id,
+			grant.WithAnnotation(&v2.GrantExpandable{
+				EntitlementIds: []string{ent.NewEntitlementID(principal, "member")},
+			}),
+			grant.WithGrantMetadata(map[string]interface{}{"aggregated_to": role.Name}),
+		))
+	}
+
+	return gnts, nil
+}
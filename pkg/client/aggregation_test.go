@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func clusterRole(name string, lbls map[string]string, selectors ...map[string]string) *rbacv1.ClusterRole {
+	role := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name + "-uid"), Labels: lbls}}
+	if len(selectors) > 0 {
+		role.AggregationRule = &rbacv1.AggregationRule{}
+		for _, selector := range selectors {
+			role.AggregationRule.ClusterRoleSelectors = append(role.AggregationRule.ClusterRoleSelectors, metav1.LabelSelector{MatchLabels: selector})
+		}
+	}
+	return role
+}
+
+func aggregationFixture() []runtime.Object {
+	toEdit := map[string]string{"aggregate-to-edit": "true"}
+	toView := map[string]string{"aggregate-to-view": "true"}
+	return []runtime.Object{
+		clusterRole("edit", nil, toEdit),
+		clusterRole("view", nil, toView),
+		clusterRole("crd-edit", toEdit),
+		clusterRole("crd-view", map[string]string{"aggregate-to-edit": "true", "aggregate-to-view": "true"}),
+		clusterRole("unrelated", nil),
+		// an empty selector selects nothing
+		clusterRole("empty", nil, map[string]string{}),
+	}
+}
+
+func names(roles []rbacv1.ClusterRole) []string {
+	var n []string
+	for _, role := range roles {
+		n = append(n, role.Name)
+	}
+	return n
+}
+
+func TestAggregationContributors(t *testing.T) {
+	tests := []struct {
+		name      string
+		roles     []string
+		want      map[string][]string
+		wantLists int
+	}{
+		{
+			name:      "aggregated roles",
+			roles:     []string{"edit", "view", "unrelated"},
+			want:      map[string][]string{"edit": {"crd-edit", "crd-view"}, "view": {"crd-view"}},
+			wantLists: 1,
+		},
+		{
+			name:      "empty selector",
+			roles:     []string{"empty"},
+			want:      map[string][]string{"empty": nil},
+			wantLists: 1,
+		},
+		{
+			name:  "no aggregated role",
+			roles: []string{"crd-edit", "unrelated"},
+			want:  map[string][]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := aggregationFixture()
+			k8s := k8sfake.NewSimpleClientset(objs...)
+			c := &Client{k8sClient: k8s, defaultPageSize: DefaultPageSize}
+
+			var roles []rbacv1.ClusterRole
+			for _, name := range tt.roles {
+				for _, obj := range objs {
+					if role := obj.(*rbacv1.ClusterRole); role.Name == name {
+						roles = append(roles, *role)
+					}
+				}
+			}
+			for range 2 {
+				contributors, err := c.aggregationContributors(context.Background(), roles)
+				require.NoError(t, err)
+				got := map[string][]string{}
+				for role, roles := range contributors {
+					got[role] = names(roles)
+				}
+				require.Equal(t, tt.want, got)
+			}
+			// the cluster roles are listed once per sync
+			require.Len(t, k8s.Actions(), tt.wantLists)
+		})
+	}
+}
+
+func TestAggregationGrants(t *testing.T) {
+	tests := []struct {
+		name        string
+		contributor string
+		want        []string
+	}{
+		{name: "aggregated to one role", contributor: "crd-edit", want: []string{"edit"}},
+		{name: "aggregated to many roles", contributor: "crd-view", want: []string{"edit", "view"}},
+		{name: "not aggregated", contributor: "unrelated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8s := k8sfake.NewSimpleClientset(aggregationFixture()...)
+			c := &Client{k8sClient: k8s, defaultPageSize: DefaultPageSize}
+			entitlement, err := convertV1ClusterRole2Resource(*clusterRole(tt.contributor, nil), nil)
+			require.NoError(t, err)
+
+			for range 2 {
+				gnts, err := c.AggregationGrants(context.Background(), entitlement)
+				require.NoError(t, err)
+				var got []string
+				for _, gnt := range gnts {
+					require.Equal(t, clusterRoleResourceTypeID, gnt.Principal.Id.ResourceType)
+					got = append(got, gnt.Principal.Id.Resource)
+				}
+				var want []string
+				for _, name := range tt.want {
+					want = append(want, name+"-uid")
+				}
+				require.Equal(t, want, got)
+			}
+			require.Len(t, k8s.Actions(), 1)
+		})
+	}
+}
@@ -0,0 +1,329 @@
+package client
+
+// cache.go have an informer based backend for the client. When the
+// connector runs as a service, syncs are served from a local copy of
+// the objects that is kept up to date by watches instead of listing
+// the API server from scratch on every sync.
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	v1 "github.com/openshift/api/user/v1"
+	userclient "github.com/openshift/client-go/user/clientset/versioned"
+	userinformers "github.com/openshift/client-go/user/informers/externalversions"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+var (
+	usersResource               = v1.GroupVersion.WithResource("users")
+	groupsResource              = v1.GroupVersion.WithResource("groups")
+	rolesResource               = rbacv1.SchemeGroupVersion.WithResource("roles")
+	roleBindingsResource        = rbacv1.SchemeGroupVersion.WithResource("rolebindings")
+	clusterRolesResource        = rbacv1.SchemeGroupVersion.WithResource("clusterroles")
+	clusterRoleBindingsResource = rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings")
+	namespacesResource          = corev1.SchemeGroupVersion.WithResource("namespaces")
+	serviceAccountsResource     = corev1.SchemeGroupVersion.WithResource("serviceaccounts")
+)
+
+// cacheSyncTimeout bounds the wait for the first listing of an informer,
+// an informer that may not list or watch its resource retries forever.
+var cacheSyncTimeout = 2 * time.Minute
+
+// cacheableResources are the resources the cache can serve, each
+// informer is started the first time its resource is read.
+var cacheableResources = map[schema.GroupVersionResource]bool{
+	usersResource:               true,
+	groupsResource:              true,
+	rolesResource:               true,
+	roleBindingsResource:        true,
+	clusterRolesResource:        true,
+	clusterRoleBindingsResource: true,
+	namespacesResource:          true,
+	serviceAccountsResource:     true,
+}
+
+// metadataOnlyResources are cached without their spec nor status, the
+// connector only reads the metadata of these.
+var metadataOnlyResources = map[schema.GroupVersionResource]bool{
+	usersResource:           true,
+	namespacesResource:      true,
+	serviceAccountsResource: true,
+}
+
+// namespacedResources are watched in each synced namespace, unless every
+// namespace is synced: the credentials may be limited to the synced
+// namespaces.
+var namespacedResources = map[schema.GroupVersionResource]bool{
+	rolesResource:           true,
+	roleBindingsResource:    true,
+	serviceAccountsResource: true,
+}
+
+// informerKey is a resource watched in a namespace, or cluster-wide
+// when the namespace is empty.
+type informerKey struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+}
+
+// cache serves objects from shared informers.
+type cache struct {
+	// stop is closed when the context the client was created with
+	// is done, stopping all the informers.
+	stop <-chan struct{}
+	// allNamespaces watches the namespaced resources cluster-wide.
+	allNamespaces bool
+	k8sClient     kubernetes.Interface
+	metaClient    metadata.Interface
+
+	mu sync.Mutex
+	// k8s and metadata are the factories of the informers by namespace,
+	// the empty namespace is cluster-wide.
+	k8s       map[string]informers.SharedInformerFactory
+	users     userinformers.SharedInformerFactory
+	metadata  map[string]metadatainformer.SharedInformerFactory
+	informers map[informerKey]informers.GenericInformer
+}
+
+func newCache(ctx context.Context, c *rest.Config, namespaces []string) (*cache, error) {
+	// watches are long-running requests, the request timeout would
+	// cut them short
+	c = rest.CopyConfig(c)
+	c.Timeout = 0
+	k8sc, err := kubernetes.NewForConfig(c)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the k8s clientset for the informers, error: %w", err)
+	}
+	metac, err := metadata.NewForConfig(c)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the metadata client for the informers, error: %w", err)
+	}
+	usrc, err := userclient.NewForConfig(c)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the user clientset for the informers, error: %w", err)
+	}
+
+	return &cache{
+		stop:          ctx.Done(),
+		allNamespaces: slices.Contains(namespaces, AllNamespaces),
+		k8sClient:     k8sc,
+		metaClient:    metac,
+		k8s:           map[string]informers.SharedInformerFactory{},
+		users:         userinformers.NewSharedInformerFactoryWithOptions(usrc, 0, userinformers.WithTransform(stripManagedFields)),
+		metadata:      map[string]metadatainformer.SharedInformerFactory{},
+		informers:     map[informerKey]informers.GenericInformer{},
+	}, nil
+}
+
+// k8sFactory returns the factory of the informers of a namespace, c.mu
+// must be held.
+func (c *cache) k8sFactory(namespace string) informers.SharedInformerFactory {
+	factory, ok := c.k8s[namespace]
+	if !ok {
+		factory = informers.NewSharedInformerFactoryWithOptions(c.k8sClient, 0,
+			informers.WithNamespace(namespace),
+			informers.WithTransform(stripManagedFields),
+		)
+		c.k8s[namespace] = factory
+	}
+	return factory
+}
+
+// metadataFactory returns the factory of the metadata informers of a
+// namespace, c.mu must be held.
+func (c *cache) metadataFactory(namespace string) metadatainformer.SharedInformerFactory {
+	factory, ok := c.metadata[namespace]
+	if !ok {
+		// the factory has no options along a namespace, the transform is
+		// set on each informer
+		factory = metadatainformer.NewFilteredSharedInformerFactory(c.metaClient, 0, namespace, nil)
+		c.metadata[namespace] = factory
+	}
+	return factory
+}
+
+// informer returns the informer of a resource in a namespace, starting
+// it and waiting for its first listing if it wasn't started before. The
+// cluster-scoped resources, and the namespaced ones when every namespace
+// is synced, share a cluster-wide informer.
+func (c *cache) informer(ctx context.Context, gvr schema.GroupVersionResource, namespace string) (informers.GenericInformer, error) {
+	if !cacheableResources[gvr] {
+		return nil, fmt.Errorf("resource %s is not cacheable", gvr)
+	}
+	key := informerKey{gvr: gvr}
+	if namespacedResources[gvr] && !c.allNamespaces {
+		key.namespace = namespace
+	}
+
+	c.mu.Lock()
+	inf, ok := c.informers[key]
+	if !ok {
+		var err error
+		switch {
+		case metadataOnlyResources[gvr]:
+			inf = c.metadataFactory(key.namespace).ForResource(gvr)
+			// the transform is set before the informer starts
+			err = inf.Informer().SetTransform(stripManagedFields)
+		case gvr.Group == v1.GroupName:
+			inf, err = c.users.ForResource(gvr)
+		default:
+			inf, err = c.k8sFactory(key.namespace).ForResource(gvr)
+		}
+		if err != nil {
+			c.mu.Unlock()
+			return nil, fmt.Errorf("unable to create informer for %s, error: %w", gvr, err)
+		}
+		// informers are started by the factories only once they are requested
+		inf.Informer()
+		for _, factory := range c.k8s {
+			factory.Start(c.stop)
+		}
+		c.users.Start(c.stop)
+		for _, factory := range c.metadata {
+			factory.Start(c.stop)
+		}
+		c.informers[key] = inf
+		ctxzap.Extract(ctx).Info("started informer, waiting for the cache to sync",
+			zap.Stringer("resource", gvr),
+			zap.String("namespace", key.namespace),
+		)
+	}
+	c.mu.Unlock()
+
+	syncCtx, cancel := context.WithTimeout(ctx, cacheSyncTimeout)
+	defer cancel()
+	if !toolscache.WaitForCacheSync(syncCtx.Done(), inf.Informer().HasSynced) {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("cache of %s didn't sync, error: %w", gvr, ctx.Err())
+		}
+		return nil, fmt.Errorf("cache of %s didn't sync within %s, check that it may be listed and watched", gvr, cacheSyncTimeout)
+	}
+
+	return inf, nil
+}
+
+// stripManagedFields drops the managed fields of the objects before they
+// are stored, these are often bigger than the rest of the object.
+func stripManagedFields(obj any) (any, error) {
+	if accessor, err := meta.Accessor(obj); err == nil {
+		accessor.SetManagedFields(nil)
+	}
+	return obj, nil
+}
+
+// objects returns the cached objects of a resource sorted by namespace
+// and name, so pages stay stable between calls.
+func (c *cache) objects(ctx context.Context, gvr schema.GroupVersionResource, namespace string) ([]runtime.Object, error) {
+	inf, err := c.informer(ctx, gvr, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []runtime.Object
+	if namespace != "" {
+		objs, err = inf.Lister().ByNamespace(namespace).List(labels.Everything())
+	} else {
+		objs, err = inf.Lister().List(labels.Everything())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(objs, func(i, j int) bool {
+		a, _ := meta.Accessor(objs[i])
+		b, _ := meta.Accessor(objs[j])
+		if a.GetNamespace() != b.GetNamespace() {
+			return a.GetNamespace() < b.GetNamespace()
+		}
+		return a.GetName() < b.GetName()
+	})
+
+	return objs, nil
+}
+
+// cachedList serves a listFunc from the cache, the continue token is
+// the offset of the next page.
+func cachedList[T any](c *cache, gvr schema.GroupVersionResource, namespace string) listFunc[T] {
+	return func(ctx context.Context, opts metav1.ListOptions) ([]T, string, error) {
+		objs, err := c.objects(ctx, gvr, namespace)
+		if err != nil {
+			return nil, "", err
+		}
+
+		offset := 0
+		if opts.Continue != "" {
+			offset, err = strconv.Atoi(opts.Continue)
+			// the cache shrank, or the token comes from the API server
+			if err != nil || offset < 0 || offset > len(objs) {
+				return nil, "", apierrors.NewResourceExpired("continue token doesn't match the cache")
+			}
+		}
+		end := len(objs)
+		if opts.Limit > 0 && offset+int(opts.Limit) < end {
+			end = offset + int(opts.Limit)
+		}
+
+		items := make([]T, 0, end-offset)
+		for _, obj := range objs[offset:end] {
+			item, ok := any(obj).(*T)
+			if !ok {
+				return nil, "", fmt.Errorf("unexpected object %T in the cache of %s", obj, gvr)
+			}
+			items = append(items, *item)
+		}
+
+		next := ""
+		if end < len(objs) {
+			next = strconv.Itoa(end)
+		}
+
+		return items, next, nil
+	}
+}
+
+// cachedGet returns an object of the cache by its name, it fails with a
+// NotFound error like the API server does.
+func cachedGet[T any](ctx context.Context, c *cache, gvr schema.GroupVersionResource, namespace, name string) (*T, error) {
+	inf, err := c.informer(ctx, gvr, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj runtime.Object
+	if namespace != "" {
+		obj, err = inf.Lister().ByNamespace(namespace).Get(name)
+	} else {
+		obj, err = inf.Lister().Get(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	item, ok := any(obj).(*T)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %T in the cache of %s", obj, gvr)
+	}
+
+	return item, nil
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	userinformers "github.com/openshift/client-go/user/informers/externalversions"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/metadata/metadatainformer"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newTestCache(ctx context.Context, k8s *k8sfake.Clientset) *cache {
+	return &cache{
+		stop:       ctx.Done(),
+		k8sClient:  k8s,
+		metaClient: newMetadataFake(),
+		k8s:        map[string]informers.SharedInformerFactory{},
+		users:      userinformers.NewSharedInformerFactory(userfake.NewSimpleClientset(), 0),
+		metadata:   map[string]metadatainformer.SharedInformerFactory{},
+		informers:  map[informerKey]informers.GenericInformer{},
+	}
+}
+
+func TestCachedList(t *testing.T) {
+	role := func(name string) runtime.Object {
+		return &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: name}}
+	}
+	tests := []struct {
+		name      string
+		opts      metav1.ListOptions
+		wantNames []string
+		wantNext  string
+		wantErr   bool
+	}{
+		{name: "first page", opts: metav1.ListOptions{Limit: 2}, wantNames: []string{"admin", "edit"}, wantNext: "2"},
+		{name: "last page", opts: metav1.ListOptions{Limit: 2, Continue: "2"}, wantNames: []string{"view"}},
+		{name: "every object", wantNames: []string{"admin", "edit", "view"}},
+		{name: "token of the API server", opts: metav1.ListOptions{Continue: "eyJ2IjoibWV0YS5rOHMuaW8vdjEifQ"}, wantErr: true},
+		{name: "token past the end", opts: metav1.ListOptions{Continue: "4"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			c := newTestCache(ctx, k8sfake.NewSimpleClientset(role("view"), role("admin"), role("edit")))
+
+			items, next, err := cachedList[rbacv1.Role](c, rolesResource, "team-a")(ctx, tt.opts)
+			if tt.wantErr {
+				require.True(t, apierrors.IsResourceExpired(err))
+				return
+			}
+			require.NoError(t, err)
+			names := make([]string, 0, len(items))
+			for _, item := range items {
+				names = append(names, item.Name)
+			}
+			require.Equal(t, tt.wantNames, names)
+			require.Equal(t, tt.wantNext, next)
+		})
+	}
+}
+
+func TestCacheSyncTimeout(t *testing.T) {
+	timeout := cacheSyncTimeout
+	cacheSyncTimeout = 100 * time.Millisecond
+	defer func() { cacheSyncTimeout = timeout }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	k8s := k8sfake.NewSimpleClientset()
+	// an informer that may not list its resource never syncs
+	k8s.PrependReactor("list", "roles", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(rolesResource.GroupResource(), "", nil)
+	})
+	c := newTestCache(ctx, k8s)
+
+	_, err := c.informer(ctx, rolesResource, "team-a")
+	require.ErrorContains(t, err, "didn't sync within")
+}
+
+func TestCacheInformerScope(t *testing.T) {
+	tests := []struct {
+		name          string
+		allNamespaces bool
+		gvr           schema.GroupVersionResource
+		// wantNamespaces are the namespaces listed by the informers
+		wantNamespaces []string
+	}{
+		{name: "synced namespaces", gvr: rolesResource, wantNamespaces: []string{"team-a", "team-b"}},
+		{name: "every namespace", allNamespaces: true, gvr: rolesResource, wantNamespaces: []string{""}},
+		{name: "cluster-scoped", gvr: clusterRolesResource, wantNamespaces: []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			k8s := k8sfake.NewSimpleClientset()
+			c := newTestCache(ctx, k8s)
+			c.allNamespaces = tt.allNamespaces
+
+			for _, namespace := range []string{"team-a", "team-b", "team-a"} {
+				_, err := c.informer(ctx, tt.gvr, namespace)
+				require.NoError(t, err)
+			}
+			var namespaces []string
+			for _, action := range k8s.Actions() {
+				if action.GetVerb() == "list" {
+					namespaces = append(namespaces, action.GetNamespace())
+				}
+			}
+			require.ElementsMatch(t, tt.wantNamespaces, namespaces)
+		})
+	}
+}
@@ -3,115 +3,587 @@ package client
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sync"
+	"time"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
 	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	v1 "github.com/openshift/api/user/v1"
+	oauthv1 "github.com/openshift/client-go/oauth/clientset/versioned/typed/oauth/v1"
+	securityv1 "github.com/openshift/client-go/security/clientset/versioned/typed/security/v1"
 	userv1 "github.com/openshift/client-go/user/clientset/versioned/typed/user/v1"
+	"go.uber.org/zap"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
 )
 
 // Client is an abstraction that sits between Openshift/Kubernetes Go
 // API client and the Baton connector code needed by Baton SDK.
 type Client struct {
-	usersClient *userv1.UserV1Client
-	k8sClient   *kubernetes.Clientset
+	usersClient    userv1.UserV1Interface
+	securityClient securityv1.SecurityV1Interface
+	oauthClient    oauthv1.OauthV1Interface
+	k8sClient      kubernetes.Interface
+	// metadataClient lists objects without their spec nor status.
+	metadataClient metadata.Interface
+
+	defaultPageSize int64
+	namespaces      []string
+	concurrency     int
+	// indexTTL is how long the indexes of whole listings are reused.
+	indexTTL time.Duration
+	// impersonate is who the requests are made as, if not the
+	// credentials themselves.
+	impersonate rest.ImpersonationConfig
+	// served tells whether the API server serves the group versions
+	// that are optional (e.g. the user API of OpenShift) once discovered.
+	servedMu sync.Mutex
+	served   map[schema.GroupVersion]bool
+	// filters select the synced objects.
+	filters Filters
+	// cluster prefixes the IDs of the resources, when many clusters are synced.
+	cluster string
+	// permissionEntitlements breaks the roles down into permissions,
+	// discovered holds the resources their wildcards expand to.
+	permissionEntitlements bool
+	apiResourcesMu         sync.Mutex
+	discovered             map[string]map[string][]string
+	// aggregation indexes the aggregation rules of the cluster roles.
+	aggregation ttlIndex[*aggregation]
+	// sccPolicy holds the roles allowing to use SCCs and their bindings.
+	sccPolicy ttlIndex[*sccPolicy]
+	// roleBindings maps the cluster roles to the role bindings that
+	// grant them, by namespace.
+	roleBindings ttlIndex[map[string]map[string][]rbacv1.RoleBinding]
+	// users maps the names of the users to their resource IDs.
+	users ttlIndex[map[string]*v2.ResourceId]
+	// synced is the set of the synced namespaces.
+	synced ttlIndex[map[string]bool]
+	// groups and serviceAccounts (by namespace) map the names of the
+	// subjects of role bindings to their resource IDs.
+	groups          ttlIndex[map[string]*v2.ResourceId]
+	serviceAccounts ttlIndexes[string, map[string]*v2.ResourceId]
+	// lastLogin sets the logins of the users from their access tokens,
+	// logins maps the names of the users to them.
+	lastLogin bool
+	logins    ttlIndex[map[string]login]
+	// cache is only set when the informer backend is enabled.
+	cache    *cache
+	useCache bool
+}
+
+// Option customizes the Client returned by New.
+type Option func(*Client)
+
+// WithPageSize sets how many objects are requested per page when
+// the SDK doesn't ask for a specific size.
+func WithPageSize(size int) Option {
+	return func(c *Client) {
+		if size > 0 {
+			c.defaultPageSize = int64(size)
+		}
+	}
 }
 
-func New(c *rest.Config) (*Client, error) {
+// WithNamespaces sets the namespaces to sync, AllNamespaces selects
+// every namespace of the cluster.
+func WithNamespaces(namespaces ...string) Option {
+	return func(c *Client) {
+		c.namespaces = namespaces
+	}
+}
+
+// WithConcurrency sets how many namespaces are listed at the same time.
+func WithConcurrency(concurrency int) Option {
+	return func(c *Client) {
+		if concurrency > 0 {
+			c.concurrency = concurrency
+		}
+	}
+}
+
+// WithInformerCache serves the listings from a cache kept up to date
+// by watches, rather than listing the API server on every sync. It is
+// meant for long-running service mode.
+func WithInformerCache(enabled bool) Option {
+	return func(c *Client) {
+		c.useCache = enabled
+	}
+}
+
+// New creates a Client, the informers of the cache (if enabled) run
+// until `ctx` is done.
+func New(ctx context.Context, c *rest.Config, opts ...Option) (*Client, error) {
+	// all the clients share one rate limiter, so concurrent listings
+	// stay within the QPS and burst of the configuration
+	c = rest.CopyConfig(c)
+	if c.RateLimiter == nil && c.QPS >= 0 {
+		qps, burst := c.QPS, c.Burst
+		if qps == 0 {
+			qps = rest.DefaultQPS
+		}
+		if burst == 0 {
+			burst = rest.DefaultBurst
+		}
+		c.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(qps, burst)
+	}
+
 	usrc, err := userv1.NewForConfig(c)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create UserV1Client client, error: %w", err)
 	}
-	k8sc, err := kubernetes.NewForConfig(c)
+	oauthc, err := oauthv1.NewForConfig(c)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create OauthV1Client client, error: %w", err)
+	}
+	secc, err := securityv1.NewForConfig(c)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create SecurityV1Client client, error: %w", err)
+	}
+	// built-in types are requested as protobuf, it is smaller on the
+	// wire and faster to decode than JSON
+	k8sConfig := rest.CopyConfig(c)
+	k8sConfig.ContentType = runtime.ContentTypeProtobuf
+	k8sConfig.AcceptContentTypes = runtime.ContentTypeProtobuf + "," + runtime.ContentTypeJSON
+	k8sc, err := kubernetes.NewForConfig(k8sConfig)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create the k8s client, error: %w", err)
 	}
+	metac, err := metadata.NewForConfig(c)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the metadata client, error: %w", err)
+	}
+
+	clt := &Client{
+		usersClient:     usrc,
+		securityClient:  secc,
+		oauthClient:     oauthc,
+		k8sClient:       k8sc,
+		metadataClient:  metac,
+		defaultPageSize: DefaultPageSize,
+		concurrency:     DefaultConcurrency,
+		indexTTL:        DefaultIndexTTL,
+		impersonate:     c.Impersonate,
+	}
+	for _, opt := range opts {
+		opt(clt)
+	}
+	if clt.useCache {
+		clt.cache, err = newCache(ctx, c, clt.namespaces)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	return &Client{usersClient: usrc, k8sClient: k8sc}, nil
+	return clt, nil
 }
 
-// ListUsers list the users of the Openshift cluster.
-func (c *Client) ListUsers(ctx context.Context) ([]*v2.Resource, error) {
-	list, err := c.usersClient.Users().List(ctx, metav1.ListOptions{})
+// listUsers only fetches the metadata of the users, that's all the
+// connector needs to know about them.
+func (c *Client) listUsers(ctx context.Context, opts metav1.ListOptions) ([]metav1.PartialObjectMetadata, string, error) {
+	if c.cache != nil {
+		return cachedList[metav1.PartialObjectMetadata](c.cache, usersResource, "")(ctx, opts)
+	}
+	list, err := c.metadataClient.Resource(usersResource).List(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	return list.Items, list.Continue, nil
+}
+
+func (c *Client) listGroups(ctx context.Context, opts metav1.ListOptions) ([]v1.Group, string, error) {
+	if c.cache != nil {
+		return cachedList[v1.Group](c.cache, groupsResource, "")(ctx, opts)
+	}
+	list, err := c.usersClient.Groups().List(ctx, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	return list.Items, list.Continue, nil
+}
+
+func (c *Client) getGroup(ctx context.Context, name string) (*v1.Group, error) {
+	if c.cache != nil {
+		return cachedGet[v1.Group](ctx, c.cache, groupsResource, "", name)
+	}
+	return withRetry(ctx, func() (*v1.Group, error) {
+		return c.usersClient.Groups().Get(ctx, name, metav1.GetOptions{})
+	})
+}
+
+func (c *Client) listRoles(namespace string) listFunc[rbacv1.Role] {
+	if c.cache != nil {
+		return cachedList[rbacv1.Role](c.cache, rolesResource, namespace)
+	}
+	return func(ctx context.Context, opts metav1.ListOptions) ([]rbacv1.Role, string, error) {
+		list, err := c.k8sClient.RbacV1().Roles(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return list.Items, list.Continue, nil
+	}
+}
+
+func (c *Client) listRoleBindings(namespace string) listFunc[rbacv1.RoleBinding] {
+	if c.cache != nil {
+		return cachedList[rbacv1.RoleBinding](c.cache, roleBindingsResource, namespace)
+	}
+	return func(ctx context.Context, opts metav1.ListOptions) ([]rbacv1.RoleBinding, string, error) {
+		list, err := c.k8sClient.RbacV1().RoleBindings(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return list.Items, list.Continue, nil
+	}
+}
+
+// listServiceAccounts only fetches the metadata of the service accounts.
+func (c *Client) listServiceAccounts(namespace string) listFunc[metav1.PartialObjectMetadata] {
+	if c.cache != nil {
+		return cachedList[metav1.PartialObjectMetadata](c.cache, serviceAccountsResource, namespace)
+	}
+	return func(ctx context.Context, opts metav1.ListOptions) ([]metav1.PartialObjectMetadata, string, error) {
+		list, err := c.metadataClient.Resource(serviceAccountsResource).Namespace(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return list.Items, list.Continue, nil
+	}
+}
+
+// userIndex maps the name of every user of the cluster to its resource
+// ID, it is reused until stale.
+func (c *Client) userIndex(ctx context.Context) (map[string]*v2.ResourceId, error) {
+	return c.users.get(c.staleAfter(), func() (map[string]*v2.ResourceId, error) {
+		list, err := listAll(ctx, c, c.listUsers)
+		if err != nil {
+			return nil, err
+		}
+
+		list = filterObjects(c.filters.Users, list)
+		index := make(map[string]*v2.ResourceId, len(list))
+		for _, user := range list {
+			index[user.Name] = c.scopeID(&v2.ResourceId{ResourceType: userResourceTypeID, Resource: string(user.UID)})
+		}
+
+		return index, nil
+	})
+}
+
+// userNameOf returns the name of the synced user with the given ID.
+func (c *Client) userNameOf(ctx context.Context, id *v2.ResourceId) (string, error) {
+	if id.ResourceType != userResourceTypeID {
+		return "", fmt.Errorf("%s %s isn't a user", id.ResourceType, id.Resource)
+	}
+	index, err := c.userIndex(ctx)
+	if err != nil {
+		return "", fmt.Errorf("unable to list users, error: %w", err)
+	}
+	for name, user := range index {
+		if user.Resource == id.Resource {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("user %s isn't synced", id.Resource)
+}
+
+// ListUsers list a page of users of the Openshift cluster. Once all the
+// User objects are listed, it goes through the groups to list their
+// members that have no User object as external users. Without the user
+// API, the users are derived from the subjects of the role bindings.
+// With WithLastLogin, their logins are set from the OAuth access tokens.
+func (c *Client) ListUsers(ctx context.Context, pToken *pagination.Token) ([]*v2.Resource, string, error) {
+	userAPI, err := c.hasUserAPI(ctx)
+	if err != nil {
+		return nil, "", err
 	}
-	users, err := convertV1Users2Resources(list.Items)
+	if !userAPI {
+		users, err := c.listDerivedUsers(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		return c.scope(users), "", nil
+	}
+
+	bag := &pagination.Bag{}
+	if err := bag.Unmarshal(pToken.Token); err != nil {
+		return nil, "", err
+	}
+	if bag.Current() == nil {
+		bag.Push(pagination.PageState{ResourceTypeID: userResourceTypeID})
+	}
+	state := bag.Current()
+	page := &pagination.Token{Size: pToken.Size, Token: state.Token}
+
+	var users []*v2.Resource
+	var next string
+	switch state.ResourceTypeID {
+	case userResourceTypeID:
+		var list []metav1.PartialObjectMetadata
+		list, next, err = listPage(ctx, c, page, c.listUsers)
+		if err != nil {
+			return nil, "", err
+		}
+		users, err = convertV1Users2Resources(filterObjects(c.filters.Users, list))
+		if err != nil {
+			return nil, "", fmt.Errorf("unable to convert users metadata to []*v2.Resource, error: %w", err)
+		}
+		if next == "" {
+			bag.Pop()
+			bag.Push(pagination.PageState{ResourceTypeID: groupResourceTypeID})
+		}
+	case groupResourceTypeID:
+		users, next, err = c.listExternalUsers(ctx, page)
+		if err != nil {
+			return nil, "", err
+		}
+		if next == "" {
+			bag.Pop()
+		}
+	default:
+		return nil, "", fmt.Errorf("unexpected page state for users: %s", state.ResourceTypeID)
+	}
+
+	if next != "" {
+		if err := bag.Next(next); err != nil {
+			return nil, "", err
+		}
+	}
+	token, err := bag.Marshal()
 	if err != nil {
-		return nil, fmt.Errorf("unable to convert []v1.User to []*v2.Resource, error: %w", err)
+		return nil, "", err
+	}
+	users, err = c.withLogins(ctx, users)
+	if err != nil {
+		return nil, "", err
 	}
 
-	return users, nil
+	return c.scope(users), token, nil
 }
 
-// ListRoles list the available (roles) entitlements in a namespace.
-func (c *Client) ListRoles(ctx context.Context, namespace string) ([]*v2.Resource, error) {
-	list, err := c.k8sClient.RbacV1().Roles(namespace).List(ctx, metav1.ListOptions{})
+// listExternalUsers list the members of a page of groups that don't
+// have a User object on the cluster.
+func (c *Client) listExternalUsers(ctx context.Context, pToken *pagination.Token) ([]*v2.Resource, string, error) {
+	groups, next, err := listPage(ctx, c, pToken, c.listGroups)
+	if err != nil {
+		return nil, "", err
+	}
+	index, err := c.userIndex(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("unable to list entitlements, error: %w", err)
+		return nil, "", err
 	}
 
-	roles, err := convertV1RoleLists2Resources(list.Items)
+	var users []*v2.Resource
+	seen := map[string]bool{}
+	for _, group := range filterObjects(c.filters.Groups, groups) {
+		for _, member := range group.Users {
+			if _, ok := index[member]; ok || seen[member] || !c.filters.Users.Match(member, nil) {
+				continue
+			}
+			seen[member] = true
+			user, err := convertExternalUser2Resource(member)
+			if err != nil {
+				return nil, "", fmt.Errorf("external user %s, error: %w", member, err)
+			}
+			users = append(users, user)
+		}
+	}
+
+	return users, next, nil
+}
+
+// ListServiceAccounts list a page of the service accounts of the synced namespaces.
+func (c *Client) ListServiceAccounts(ctx context.Context, pToken *pagination.Token) ([]*v2.Resource, string, error) {
+	list, next, err := namespacedPage(ctx, c, pToken, c.listServiceAccounts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	serviceAccounts, err := convertV1ServiceAccounts2Resources(filterObjects(c.filters.ServiceAccounts, list))
 	if err != nil {
-		return nil, fmt.Errorf("unable to convert []v1.Role to []*v2.Resource, error: %w", err)
+		return nil, "", fmt.Errorf("unable to convert service accounts metadata to []*v2.Resource, error: %w", err)
 	}
 
-	return roles, nil
+	return c.scope(serviceAccounts), next, nil
 }
 
-// ListRoleBindings matches a user with a role (rolebinding) in a namespace.
-func (c *Client) ListRoleBindings(ctx context.Context, namespace string, entitlement *v2.Resource, users []*v2.Resource) ([]*v2.Grant, error) {
-	list, err := c.k8sClient.RbacV1().RoleBindings(namespace).List(ctx, metav1.ListOptions{})
+// ListRoles list a page of the available (roles) entitlements of the synced namespaces.
+func (c *Client) ListRoles(ctx context.Context, pToken *pagination.Token) ([]*v2.Resource, string, error) {
+	list, next, err := namespacedPage(ctx, c, pToken, c.listRoles)
 	if err != nil {
-		return nil, fmt.Errorf("unable to list grants, error: %w", err)
+		return nil, "", fmt.Errorf("unable to list entitlements, error: %w", err)
 	}
 
-	grants, err := convertV1RoleBindings2Resources(list.Items, entitlement, users)
+	roles, err := convertV1RoleLists2Resources(filterObjects(c.filters.Roles, list))
 	if err != nil {
-		return nil, fmt.Errorf("unable to convert []v1.RoleBinding to []*v2.Grant, error: %w", err)
+		return nil, "", fmt.Errorf("unable to convert []v1.Role to []*v2.Resource, error: %w", err)
 	}
 
-	return grants, nil
+	return c.scope(roles), next, nil
 }
 
-// ListGroups list all available groups on the Openshift cluster.
-func (c *Client) ListGroups(ctx context.Context) ([]*v2.Resource, error) {
-	list, err := c.usersClient.Groups().List(ctx, metav1.ListOptions{})
+// ListRoleBindings matches the subjects (users, groups and service
+// accounts) of the role bindings of a role, for a page of the role
+// bindings of its namespace.
+func (c *Client) ListRoleBindings(ctx context.Context, entitlement *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, error) {
+	role, err := roleRefOf(entitlement)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	list, next, err := listPage(ctx, c, pToken, c.listRoleBindings(role.Namespace))
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to list grants, error: %w", err)
 	}
 
-	groups, err := convertV1Groups2Resources(list.Items)
+	var bindings []rbacv1.RoleBinding
+	var subjects []rbacv1.Subject
+	for _, binding := range list {
+		if binding.RoleRef.Kind != "Role" || binding.RoleRef.Name != role.Name {
+			continue
+		}
+		binding = withSubjectNamespaces(binding)
+		bindings = append(bindings, binding)
+		subjects = append(subjects, binding.Subjects...)
+	}
+	if len(bindings) == 0 {
+		return nil, next, nil
+	}
+
+	index, err := c.subjectIndexFor(ctx, subjects)
 	if err != nil {
-		return nil, fmt.Errorf("unable to convert []v1.Group to []*v2.Resource, error: %w", err)
+		return nil, "", err
 	}
 
-	return groups, nil
+	return convertV1RoleBindings2Resources(bindings, entitlement, index), next, nil
 }
 
-// MatchUsersToGroup matches what users belong to which groups.
-func (c *Client) MatchUsersToGroup(ctx context.Context, entitlement *v2.Resource, users []*v2.Resource) ([]*v2.Grant, error) {
-	var gnts []*v2.Grant
+// withSubjectNamespaces defaults the namespace of the service accounts
+// of a role binding to the namespace of the binding, the subjects are
+// copied to not modify the cache.
+func withSubjectNamespaces(binding rbacv1.RoleBinding) rbacv1.RoleBinding {
+	binding.Subjects = slices.Clone(binding.Subjects)
+	for i, subject := range binding.Subjects {
+		if subject.Kind == rbacv1.ServiceAccountKind && subject.Namespace == "" {
+			binding.Subjects[i].Namespace = binding.Namespace
+		}
+	}
+	return binding
+}
+
+// ListGroups list a page of the available groups on the Openshift cluster,
+// the virtual groups are listed with the first page. Without the user API,
+// the groups are derived from the subjects of the role bindings.
+func (c *Client) ListGroups(ctx context.Context, pToken *pagination.Token) ([]*v2.Resource, string, error) {
+	userAPI, err := c.hasUserAPI(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	if !userAPI {
+		groups, err := c.listDerivedGroups(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		virtual, err := c.listVirtualGroups()
+		if err != nil {
+			return nil, "", err
+		}
+		return c.scope(append(virtual, groups...)), "", nil
+	}
+
+	list, next, err := listPage(ctx, c, pToken, c.listGroups)
+	if err != nil {
+		return nil, "", err
+	}
+
+	groups, err := convertV1Groups2Resources(filterObjects(c.filters.Groups, list))
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to convert []v1.Group to []*v2.Resource, error: %w", err)
+	}
+	if pToken == nil || pToken.Token == "" {
+		virtual, err := c.listVirtualGroups()
+		if err != nil {
+			return nil, "", err
+		}
+		groups = append(virtual, groups...)
+	}
+
+	return c.scope(groups), next, nil
+}
+
+// MatchUsersToGroup matches what users belong to the group given as
+// entitlement. Members without a User object (e.g. users coming from
+// an external OIDC provider) are granted as external users, virtual
+// groups are expanded to the users they implicitly have.
+func (c *Client) MatchUsersToGroup(ctx context.Context, entitlement *v2.Resource) ([]*v2.Grant, error) {
+	if c.isVirtualGroup(entitlement) {
+		return c.matchUsersToVirtualGroup(ctx, entitlement)
+	}
 
-	list, err := c.usersClient.Groups().List(ctx, metav1.ListOptions{})
+	// without Group objects, the members of groups are unknown
+	userAPI, err := c.hasUserAPI(ctx)
+	if err != nil || !userAPI {
+		return nil, err
+	}
+
+	group, err := c.getGroup(ctx, entitlement.DisplayName)
 	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
 		return nil, err
 	}
-	for _, group := range list.Items {
-		// match a group with the entitlement
-		if entitlement.Id.Resource == string(group.UID) {
-			// check that the user is member of the group
-			for _, user := range users {
-				for _, member := range group.Users {
-					// NOTE(shackra): 3 levels of for-loops isn't that performing! right?
-					if user.DisplayName == member {
-						gnts = append(gnts, grant.NewGrant(entitlement, "member", user.Id))
-					}
-				}
-			}
+	// the group was deleted and created again since it was listed
+	if entitlement.Id.Resource != c.scopeID(&v2.ResourceId{Resource: string(group.UID)}).Resource {
+		return nil, nil
+	}
+
+	index, err := c.userIndex(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list users to match their group membership, error: %w", err)
+	}
+
+	// the members of groups synced from LDAP are overwritten by the
+	// next sync, they can only be changed in the directory
+	var opts []grant.GrantOption
+	if isLDAPGroup(*group) {
+		opts = append(opts, grant.WithAnnotation(&v2.GrantImmutable{}))
+	}
+
+	var gnts []*v2.Grant
+	var external []string
+	for _, member := range group.Users {
+		if id, ok := index[member]; ok {
+			gnts = append(gnts, grant.NewGrant(entitlement, "member", id, opts...))
+			continue
 		}
+		// filtered out users aren't synced
+		if !c.filters.Users.Match(member, nil) {
+			continue
+		}
+		external = append(external, member)
+		gnts = append(gnts, grant.NewGrant(
+			entitlement,
+			"member",
+			c.scopeID(externalUserResourceID(member)),
+			append(slices.Clone(opts), grant.WithGrantMetadata(map[string]interface{}{"external_member": true}))...,
+		))
+	}
+	if len(external) > 0 {
+		ctxzap.Extract(ctx).Info(
+			"group has members without a User object, granted as external users",
+			zap.String("group", group.Name),
+			zap.Strings("members", external),
+		)
 	}
 
 	return gnts, nil
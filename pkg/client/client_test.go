@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	metadatafake "k8s.io/client-go/metadata/fake"
+)
+
+// partialObject is the metadata of an object of the given resource, as
+// returned by the metadata client.
+func partialObject(gvr schema.GroupVersionResource, kind, namespace, name string, lbls map[string]string) *metav1.PartialObjectMetadata {
+	return &metav1.PartialObjectMetadata{
+		TypeMeta: metav1.TypeMeta{APIVersion: gvr.GroupVersion().String(), Kind: kind},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			UID:       types.UID(name + "-uid"),
+			Labels:    lbls,
+		},
+	}
+}
+
+func newMetadataFake(objects ...*metav1.PartialObjectMetadata) *metadatafake.FakeMetadataClient {
+	scheme := metadatafake.NewTestScheme()
+	_ = metav1.AddMetaToScheme(scheme)
+	objs := make([]runtime.Object, 0, len(objects))
+	for _, obj := range objects {
+		objs = append(objs, obj)
+	}
+	return metadatafake.NewSimpleMetadataClient(scheme, objs...)
+}
+
+func TestUserIndex(t *testing.T) {
+	users := []*metav1.PartialObjectMetadata{
+		partialObject(usersResource, "User", "", "alice", nil),
+		partialObject(usersResource, "User", "", "system:admin", nil),
+	}
+	excludeSystem, err := NewFilter(nil, OpenShiftExcludedUsers)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		filters Filters
+		want    map[string]*v2.ResourceId
+	}{
+		{
+			name: "every user",
+			want: map[string]*v2.ResourceId{
+				"alice":        {ResourceType: userResourceTypeID, Resource: "alice-uid"},
+				"system:admin": {ResourceType: userResourceTypeID, Resource: "system:admin-uid"},
+			},
+		},
+		{
+			name:    "filtered users",
+			filters: Filters{Users: excludeSystem},
+			want: map[string]*v2.ResourceId{
+				"alice": {ResourceType: userResourceTypeID, Resource: "alice-uid"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := newMetadataFake(users...)
+			c := &Client{metadataClient: meta, defaultPageSize: DefaultPageSize, filters: tt.filters}
+
+			for range 2 {
+				index, err := c.userIndex(context.Background())
+				require.NoError(t, err)
+				require.Equal(t, tt.want, index)
+			}
+			// the index is built once per sync
+			require.Len(t, meta.Actions(), 1)
+		})
+	}
+}
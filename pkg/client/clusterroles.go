@@ -0,0 +1,235 @@
+package client
+
+// clusterroles.go lists the cluster roles and matches the subjects of
+// the cluster role bindings that grant them cluster-wide, and of the
+// role bindings that grant them in a single namespace (e.g. `admin` of
+// a project), the most common way projects are shared on OpenShift.
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (c *Client) listClusterRoles(ctx context.Context, opts metav1.ListOptions) ([]rbacv1.ClusterRole, string, error) {
+	if c.cache != nil {
+		return cachedList[rbacv1.ClusterRole](c.cache, clusterRolesResource, "")(ctx, opts)
+	}
+	list, err := c.k8sClient.RbacV1().ClusterRoles().List(ctx, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	return list.Items, list.Continue, nil
+}
+
+// ListClusterRoles list a page of the cluster roles.
+func (c *Client) ListClusterRoles(ctx context.Context, pToken *pagination.Token) ([]*v2.Resource, string, error) {
+	list, next, err := listPage(ctx, c, pToken, c.listClusterRoles)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to list cluster roles, error: %w", err)
+	}
+
+	list = filterObjects(c.filters.Roles, list)
+	contributors, err := c.aggregationContributors(ctx, list)
+	if err != nil {
+		return nil, "", err
+	}
+	roles, err := convertV1ClusterRoles2Resources(list, contributors)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to convert []v1.ClusterRole to []*v2.Resource, error: %w", err)
+	}
+
+	return c.scope(roles), next, nil
+}
+
+// NamespacedMemberSlug is the slug of the entitlement of a cluster role
+// granted by role bindings of a namespace.
+func NamespacedMemberSlug(namespace string) string {
+	return "member:" + namespace
+}
+
+// namespacedBindings returns the role bindings of the synced namespaces
+// that grant a cluster role, by cluster role then by namespace. The role
+// bindings are listed once and reused until stale, the entitlements
+// and grants of every cluster role are listed one after the other.
+func (c *Client) namespacedBindings(ctx context.Context) (map[string]map[string][]rbacv1.RoleBinding, error) {
+	return c.roleBindings.get(c.staleAfter(), func() (map[string]map[string][]rbacv1.RoleBinding, error) {
+		namespaces, err := c.bindingNamespaces(ctx)
+		if err != nil {
+			return nil, err
+		}
+		index := map[string]map[string][]rbacv1.RoleBinding{}
+		for _, namespace := range namespaces {
+			bindings, err := listAll(ctx, c, c.listRoleBindings(namespace))
+			if err != nil {
+				return nil, fmt.Errorf("unable to list role bindings, namespace %s, error: %w", namespace, err)
+			}
+			for _, binding := range bindings {
+				if binding.RoleRef.Kind != "ClusterRole" {
+					continue
+				}
+				if index[binding.RoleRef.Name] == nil {
+					index[binding.RoleRef.Name] = map[string][]rbacv1.RoleBinding{}
+				}
+				index[binding.RoleRef.Name][binding.Namespace] = append(index[binding.RoleRef.Name][binding.Namespace], withSubjectNamespaces(binding))
+			}
+		}
+		return index, nil
+	})
+}
+
+// ClusterRoleNamespaces returns the sorted synced namespaces where role
+// bindings grant a cluster role.
+func (c *Client) ClusterRoleNamespaces(ctx context.Context, entitlement *v2.Resource) ([]string, error) {
+	role, err := roleRefOf(entitlement)
+	if err != nil {
+		return nil, err
+	}
+	index, err := c.namespacedBindings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(index[role.Name]))
+	for namespace := range index[role.Name] {
+		names = append(names, namespace)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// ListClusterRoleBindings matches the subjects of the bindings of a
+// cluster role: a page of the cluster role bindings, then the role
+// bindings of a batch of the synced namespaces.
+func (c *Client) ListClusterRoleBindings(ctx context.Context, entitlement *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, error) {
+	role, err := roleRefOf(entitlement)
+	if err != nil {
+		return nil, "", err
+	}
+
+	bag := &pagination.Bag{}
+	if err := bag.Unmarshal(pToken.Token); err != nil {
+		return nil, "", err
+	}
+	if bag.Current() == nil {
+		bag.Push(pagination.PageState{ResourceTypeID: clusterRoleResourceTypeID})
+	}
+	state := bag.Current()
+	page := &pagination.Token{Size: pToken.Size, Token: state.Token}
+
+	var gnts []*v2.Grant
+	var next string
+	switch state.ResourceTypeID {
+	case clusterRoleResourceTypeID:
+		gnts, next, err = c.listClusterRoleBindingGrants(ctx, entitlement, role.Name, page)
+		if err != nil {
+			return nil, "", err
+		}
+		if next == "" {
+			bag.Pop()
+			bag.Push(pagination.PageState{ResourceTypeID: namespaceResourceTypeID})
+		}
+	case namespaceResourceTypeID:
+		gnts, next, err = c.listNamespacedBindingGrants(ctx, entitlement, role.Name, page)
+		if err != nil {
+			return nil, "", err
+		}
+		if next == "" {
+			bag.Pop()
+		}
+	default:
+		return nil, "", fmt.Errorf("unexpected page state for cluster role bindings: %s", state.ResourceTypeID)
+	}
+
+	if next != "" {
+		if err := bag.Next(next); err != nil {
+			return nil, "", err
+		}
+	}
+	token, err := bag.Marshal()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return gnts, token, nil
+}
+
+// listClusterRoleBindingGrants grants a cluster role cluster-wide to the
+// subjects of a page of its cluster role bindings.
+func (c *Client) listClusterRoleBindingGrants(ctx context.Context, entitlement *v2.Resource, name string, pToken *pagination.Token) ([]*v2.Grant, string, error) {
+	list, next, err := listPage(ctx, c, pToken, c.listClusterRoleBindings)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to list grants, error: %w", err)
+	}
+
+	var bindings []rbacv1.ClusterRoleBinding
+	var subjects []rbacv1.Subject
+	for _, binding := range list {
+		if binding.RoleRef.Kind != "ClusterRole" || binding.RoleRef.Name != name {
+			continue
+		}
+		bindings = append(bindings, binding)
+		subjects = append(subjects, binding.Subjects...)
+	}
+	if len(bindings) == 0 {
+		return nil, next, nil
+	}
+
+	index, err := c.subjectIndexFor(ctx, subjects)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return convertV1ClusterRoleBindings2Resources(bindings, entitlement, index), next, nil
+}
+
+// listNamespacedBindingGrants grants a cluster role, in their namespace,
+// to the subjects of the role bindings of a batch of the namespaces
+// where it is bound, the token is the last namespace of the batch.
+func (c *Client) listNamespacedBindingGrants(ctx context.Context, entitlement *v2.Resource, name string, pToken *pagination.Token) ([]*v2.Grant, string, error) {
+	names, err := c.ClusterRoleNamespaces(ctx, entitlement)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to list grants, error: %w", err)
+	}
+	index, err := c.namespacedBindings(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to list grants, error: %w", err)
+	}
+
+	start := 0
+	if pToken != nil && pToken.Token != "" {
+		start = sort.SearchStrings(names, pToken.Token)
+		if start < len(names) && names[start] == pToken.Token {
+			start++
+		}
+	}
+	var bindings []rbacv1.RoleBinding
+	var subjects []rbacv1.Subject
+	end := start
+	for ; end < len(names) && int64(len(bindings)) < c.pageSize(pToken); end++ {
+		for _, binding := range index[name][names[end]] {
+			bindings = append(bindings, binding)
+			subjects = append(subjects, binding.Subjects...)
+		}
+	}
+	next := ""
+	if end < len(names) {
+		next = names[end-1]
+	}
+	if len(bindings) == 0 {
+		return nil, next, nil
+	}
+
+	subjectIndex, err := c.subjectIndexFor(ctx, subjects)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return convertV1ClusterRoleRoleBindings2Resources(bindings, entitlement, subjectIndex), next, nil
+}
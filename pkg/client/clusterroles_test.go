@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func roleBinding(namespace, name, kind, role string, subjects ...rbacv1.Subject) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		RoleRef:    rbacv1.RoleRef{Kind: kind, Name: role},
+		Subjects:   subjects,
+	}
+}
+
+func TestListNamespacedBindingGrants(t *testing.T) {
+	// the namespace of the service accounts defaults to the namespace of
+	// the role binding
+	deployer := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "deployer"}
+	objs := []runtime.Object{
+		roleBinding("team-a", "admin", "ClusterRole", "admin", deployer),
+		roleBinding("team-b", "admin", "ClusterRole", "admin", deployer),
+		roleBinding("team-b", "admin-2", "ClusterRole", "admin", deployer),
+		roleBinding("team-c", "admin", "ClusterRole", "admin", deployer),
+		roleBinding("team-c", "view", "ClusterRole", "view", deployer),
+		// a role of the namespace, not the cluster role of the same name
+		roleBinding("team-d", "admin", "Role", "admin", deployer),
+	}
+
+	tests := []struct {
+		name           string
+		role           string
+		size           int
+		wantNamespaces []string
+		// wantPages are the namespaces of the grants of each page
+		wantPages [][]string
+	}{
+		{
+			name:           "a namespace per page",
+			role:           "admin",
+			size:           1,
+			wantNamespaces: []string{"team-a", "team-b", "team-c"},
+			wantPages:      [][]string{{"team-a"}, {"team-b", "team-b"}, {"team-c"}},
+		},
+		{
+			name:           "namespaces until the page is full",
+			role:           "admin",
+			size:           2,
+			wantNamespaces: []string{"team-a", "team-b", "team-c"},
+			wantPages:      [][]string{{"team-a", "team-b", "team-b"}, {"team-c"}},
+		},
+		{
+			name:           "single namespace",
+			role:           "view",
+			size:           10,
+			wantNamespaces: []string{"team-c"},
+			wantPages:      [][]string{{"team-c"}},
+		},
+		{
+			name:           "not bound",
+			role:           "edit",
+			size:           10,
+			wantNamespaces: []string{},
+			wantPages:      [][]string{nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8s := k8sfake.NewSimpleClientset(objs...)
+			meta := newMetadataFake(
+				partialObject(serviceAccountsResource, "ServiceAccount", "team-a", "deployer", nil),
+				partialObject(serviceAccountsResource, "ServiceAccount", "team-b", "deployer", nil),
+				partialObject(serviceAccountsResource, "ServiceAccount", "team-c", "deployer", nil),
+				partialObject(namespacesResource, "Namespace", "", "team-a", nil),
+				partialObject(namespacesResource, "Namespace", "", "team-b", nil),
+				partialObject(namespacesResource, "Namespace", "", "team-c", nil),
+			)
+			c := servedClient()
+			c.k8sClient, c.metadataClient, c.namespaces = k8s, meta, []string{AllNamespaces}
+			entitlement, err := convertV1ClusterRole2Resource(*clusterRole(tt.role, nil), nil)
+			require.NoError(t, err)
+
+			namespaces, err := c.ClusterRoleNamespaces(context.Background(), entitlement)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantNamespaces, namespaces)
+
+			var pages [][]string
+			token := ""
+			for {
+				gnts, next, err := c.listNamespacedBindingGrants(context.Background(), entitlement, tt.role, &pagination.Token{Size: tt.size, Token: token})
+				require.NoError(t, err)
+				var page []string
+				for _, gnt := range gnts {
+					require.Equal(t, serviceAccountResourceTypeID, gnt.Principal.Id.ResourceType)
+					// the entitlement is the membership of the namespace
+					_, namespace, ok := strings.Cut(gnt.Entitlement.Id, ":"+NamespacedMemberSlug(""))
+					require.True(t, ok)
+					page = append(page, namespace)
+				}
+				pages = append(pages, page)
+				if next == "" {
+					break
+				}
+				token = next
+			}
+			require.Equal(t, tt.wantPages, pages)
+
+			// the role bindings are listed once per sync
+			var lists int
+			for _, action := range k8s.Actions() {
+				if action.GetResource().Resource == "rolebindings" {
+					lists++
+				}
+			}
+			require.Equal(t, 1, lists)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package client
+
+// clusters.go scopes the resources of a client to its cluster, when a
+// single connector syncs many clusters. The IDs of the resources are
+// prefixed with the name of the cluster, so objects of different
+// clusters never collide, and the cluster resource is their parent.
+
+import (
+	"fmt"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+)
+
+const (
+	clusterResourceTypeID = "cluster"
+	// clusterSeparator splits the name of the cluster from the ID of
+	// the object, it can't be part of the name of a cluster.
+	clusterSeparator = "/"
+)
+
+// WithCluster scopes the resources of the client to the named cluster.
+// Without it (or with an empty name) the IDs of the resources are the
+// UIDs of the objects and the resources have no parent.
+func WithCluster(name string) Option {
+	return func(c *Client) {
+		c.cluster = name
+	}
+}
+
+// ClusterResourceID returns the ID of the resource of a cluster.
+func ClusterResourceID(name string) *v2.ResourceId {
+	return &v2.ResourceId{ResourceType: clusterResourceTypeID, Resource: name}
+}
+
+// scopeID returns the ID of an object of the cluster of the client.
+func (c *Client) scopeID(id *v2.ResourceId) *v2.ResourceId {
+	if c.cluster == "" {
+		return id
+	}
+	return &v2.ResourceId{ResourceType: id.ResourceType, Resource: c.cluster + clusterSeparator + id.Resource}
+}
+
+// scope moves resources under the cluster of the client.
+func (c *Client) scope(resources []*v2.Resource) []*v2.Resource {
+	if c.cluster == "" {
+		return resources
+	}
+	for _, resource := range resources {
+		resource.Id = c.scopeID(resource.Id)
+		resource.ParentResourceId = ClusterResourceID(c.cluster)
+	}
+	return resources
+}
+
+// unscopeID returns the ID an object had before it was scoped to the
+// cluster of the client, IDs of other clusters are refused.
+func (c *Client) unscopeID(id *v2.ResourceId) (string, error) {
+	if c.cluster == "" {
+		return id.Resource, nil
+	}
+	uid, ok := strings.CutPrefix(id.Resource, c.cluster+clusterSeparator)
+	if !ok {
+		return "", fmt.Errorf("%s %s isn't a resource of cluster %s", id.ResourceType, id.Resource, c.cluster)
+	}
+	return uid, nil
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScope(t *testing.T) {
+	tests := []struct {
+		name       string
+		cluster    string
+		wantID     string
+		wantParent *v2.ResourceId
+	}{
+		{name: "single cluster", wantID: "alice-uid"},
+		{name: "one of many clusters", cluster: "prod", wantID: "prod/alice-uid", wantParent: ClusterResourceID("prod")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{cluster: tt.cluster}
+			id := &v2.ResourceId{ResourceType: userResourceTypeID, Resource: "alice-uid"}
+
+			scoped := c.scopeID(id)
+			require.Equal(t, &v2.ResourceId{ResourceType: userResourceTypeID, Resource: tt.wantID}, scoped)
+
+			resources := c.scope([]*v2.Resource{{Id: id}})
+			require.Equal(t, tt.wantID, resources[0].Id.Resource)
+			require.Equal(t, tt.wantParent, resources[0].ParentResourceId)
+		})
+	}
+}
+
+func TestUnscopeID(t *testing.T) {
+	tests := []struct {
+		name    string
+		cluster string
+		id      string
+		want    string
+		wantErr string
+	}{
+		{name: "single cluster", id: "alice-uid", want: "alice-uid"},
+		{name: "one of many clusters", cluster: "prod", id: "prod/alice-uid", want: "alice-uid"},
+		{name: "another cluster", cluster: "prod", id: "staging/alice-uid", wantErr: "isn't a resource of cluster prod"},
+		{name: "unscoped", cluster: "prod", id: "alice-uid", wantErr: "isn't a resource of cluster prod"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{cluster: tt.cluster}
+			uid, err := c.unscopeID(&v2.ResourceId{ResourceType: userResourceTypeID, Resource: tt.id})
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, uid)
+		})
+	}
+}
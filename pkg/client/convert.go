@@ -8,21 +8,39 @@ package client
 // All these helpers are used on client.go.
 
 import (
-	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
 	grant "github.com/conductorone/baton-sdk/pkg/types/grant"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	oauthv1 "github.com/openshift/api/oauth/v1"
+	securityv1 "github.com/openshift/api/security/v1"
 	v1 "github.com/openshift/api/user/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// convertV1Users2Resources (plural) convert users of Openshift to resources of Baton SDK.
-func convertV1Users2Resources(users []v1.User) ([]*v2.Resource, error) {
+const (
+	userResourceTypeID           = "user"
+	groupResourceTypeID          = "group"
+	serviceAccountResourceTypeID = "service_account"
+	namespaceResourceTypeID      = "namespace"
+	roleResourceTypeID           = "role"
+	clusterRoleResourceTypeID    = "cluster_role"
+	sccResourceTypeID            = "scc"
+	oauthClientResourceTypeID    = "oauth_client"
+)
+
+// descriptionAnnotation describes an object, OpenShift sets it on the
+// objects it creates for itself.
+const descriptionAnnotation = "kubernetes.io/description"
+
+// convertV1Users2Resources (plural) convert users of Openshift to resources of Baton SDK,
+// only the metadata of the users is needed.
+func convertV1Users2Resources(users []metav1.PartialObjectMetadata) ([]*v2.Resource, error) {
 	var rsc []*v2.Resource
 	for _, user := range users {
 		result, err := convertV1User2Resource(user)
@@ -36,7 +54,7 @@ func convertV1Users2Resources(users []v1.User) ([]*v2.Resource, error) {
 }
 
 // convertV1User2Resource (singular) convert a user to a resource, use by `convertV1Users2Resources`.
-func convertV1User2Resource(user v1.User) (*v2.Resource, error) {
+func convertV1User2Resource(user metav1.PartialObjectMetadata) (*v2.Resource, error) {
 	annos := annotations.Annotations{}
 	annos.Update(&v2.SkipEntitlementsAndGrants{})
 
@@ -53,7 +71,7 @@ func convertV1User2Resource(user v1.User) (*v2.Resource, error) {
 	return rs.NewUserResource(
 		user.Name,
 		&v2.ResourceType{
-			Id:          "user",
+			Id:          userResourceTypeID,
 			DisplayName: "User",
 			Traits: []v2.ResourceType_Trait{
 				v2.ResourceType_TRAIT_USER,
@@ -65,6 +83,85 @@ func convertV1User2Resource(user v1.User) (*v2.Resource, error) {
 	)
 }
 
+// externalUserResourceID is the ID of a group member that has no User
+// object, its name is the only thing known about it.
+func externalUserResourceID(name string) *v2.ResourceId {
+	return &v2.ResourceId{ResourceType: userResourceTypeID, Resource: name}
+}
+
+// convertExternalUser2Resource convert a group member without a User object to a resource.
+func convertExternalUser2Resource(name string) (*v2.Resource, error) {
+	annos := annotations.Annotations{}
+	annos.Update(&v2.SkipEntitlementsAndGrants{})
+
+	profile := map[string]interface{}{
+		"name":     name,
+		"external": true,
+	}
+
+	return rs.NewUserResource(
+		name,
+		&v2.ResourceType{
+			Id:          userResourceTypeID,
+			DisplayName: "User",
+			Traits: []v2.ResourceType_Trait{
+				v2.ResourceType_TRAIT_USER,
+			},
+			Annotations: annos,
+		},
+		externalUserResourceID(name).Resource,
+		[]rs.UserTraitOption{rs.WithUserProfile(profile)},
+	)
+}
+
+// externalGroupResourceID is the ID of a group only known by name, from
+// the subjects of role bindings.
+func externalGroupResourceID(name string) *v2.ResourceId {
+	return &v2.ResourceId{ResourceType: groupResourceTypeID, Resource: name}
+}
+
+// convertExternalGroup2Resource convert a group without a Group object to a resource.
+func convertExternalGroup2Resource(name string) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"name":     name,
+		"external": true,
+	}
+
+	return rs.NewGroupResource(
+		name,
+		&v2.ResourceType{
+			Id:          groupResourceTypeID,
+			DisplayName: "Team",
+			Traits: []v2.ResourceType_Trait{
+				v2.ResourceType_TRAIT_GROUP,
+			},
+		},
+		externalGroupResourceID(name).Resource,
+		[]rs.GroupTraitOption{rs.WithGroupProfile(profile)},
+	)
+}
+
+// convertVirtualGroup2Resource convert a virtual group, that users are implicitly members of, to a resource.
+func convertVirtualGroup2Resource(name string) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"name":    name,
+		"virtual": true,
+	}
+
+	return rs.NewGroupResource(
+		name,
+		&v2.ResourceType{
+			Id:          groupResourceTypeID,
+			DisplayName: "Team",
+			Traits: []v2.ResourceType_Trait{
+				v2.ResourceType_TRAIT_GROUP,
+			},
+		},
+		externalGroupResourceID(name).Resource,
+		[]rs.GroupTraitOption{rs.WithGroupProfile(profile)},
+	)
+}
+
 // convertV1RoleLists2Resources (plural) convert a list of roles of Openshift to resources of Baton SDK.
 func convertV1RoleLists2Resources(roleLists []rbacv1.Role) ([]*v2.Resource, error) {
 	var rsc []*v2.Resource
@@ -86,18 +183,23 @@ func convertV1RoleList2Resource(roleList rbacv1.Role) (*v2.Resource, error) {
 
 	profile := map[string]interface{}{
 		"name":          roleList.Name,
+		"namespace":     roleList.Namespace,
 		"generate_name": roleList.GenerateName,
+		"created_at":    roleList.CreationTimestamp.Format(time.RFC3339),
+		"labels":        stringMapProfile(roleList.Labels),
+		"annotations":   stringMapProfile(roleList.Annotations),
+		// what the role allows, without `oc describe`
+		"rules": rulesProfile(roleList.Rules),
 	}
 
 	traits := []rs.RoleTraitOption{
 		rs.WithRoleProfile(profile),
-		// FIXME(shackra): add creation time
 	}
 
 	return rs.NewRoleResource(
 		roleList.Name,
 		&v2.ResourceType{
-			Id:          "role",
+			Id:          roleResourceTypeID,
 			DisplayName: "Role",
 			Traits: []v2.ResourceType_Trait{
 				v2.ResourceType_TRAIT_ROLE,
@@ -109,50 +211,242 @@ func convertV1RoleList2Resource(roleList rbacv1.Role) (*v2.Resource, error) {
 	)
 }
 
-var errRoleNotGranted = errors.New("role not granted to this resource")
+// roleRefOf returns the name and namespace of a role (or cluster role)
+// resource, these are kept on its profile. Cluster roles have no namespace.
+func roleRefOf(role *v2.Resource) (metav1.ObjectMeta, error) {
+	trait, err := rs.GetRoleTrait(role)
+	if err != nil {
+		return metav1.ObjectMeta{}, err
+	}
+	name, ok := rs.GetProfileStringValue(trait.Profile, "name")
+	if !ok {
+		return metav1.ObjectMeta{}, fmt.Errorf("role %s has no name on its profile", role.Id.Resource)
+	}
+	namespace, ok := rs.GetProfileStringValue(trait.Profile, "namespace")
+	if !ok && role.Id.ResourceType == roleResourceTypeID {
+		return metav1.ObjectMeta{}, fmt.Errorf("role %s has no namespace on its profile", role.Id.Resource)
+	}
+
+	return metav1.ObjectMeta{Name: name, Namespace: namespace}, nil
+}
+
+// convertV1ClusterRoles2Resources (plural) convert cluster roles of Openshift to resources of Baton SDK,
+// the aggregated cluster roles are resolved with the cluster roles that `contributors` maps them to.
+func convertV1ClusterRoles2Resources(roles []rbacv1.ClusterRole, contributors map[string][]rbacv1.ClusterRole) ([]*v2.Resource, error) {
+	var rsc []*v2.Resource
+	for _, role := range roles {
+		result, err := convertV1ClusterRole2Resource(role, contributors[role.Name])
+		if err != nil {
+			return nil, fmt.Errorf("resource %s, error: %w", role.UID, err)
+		}
+		rsc = append(rsc, result)
+	}
+
+	return rsc, nil
+}
+
+// convertV1ClusterRole2Resource (singular) convert a cluster role to a resource, the rules of an
+// aggregated cluster role include those of its contributors. use by `convertV1ClusterRoles2Resources`.
+func convertV1ClusterRole2Resource(role rbacv1.ClusterRole, contributors []rbacv1.ClusterRole) (*v2.Resource, error) {
+	rules := role.Rules
+	var names []string
+	for _, contributor := range contributors {
+		rules = append(rules, contributor.Rules...)
+		names = append(names, contributor.Name)
+	}
+
+	profile := map[string]interface{}{
+		"name":          role.Name,
+		"generate_name": role.GenerateName,
+		"created_at":    role.CreationTimestamp.Format(time.RFC3339),
+		"labels":        stringMapProfile(role.Labels),
+		"annotations":   stringMapProfile(role.Annotations),
+		"rules":         rulesProfile(rules),
+	}
+	if role.AggregationRule != nil {
+		profile["aggregated_from"] = stringsProfile(names)
+	}
+
+	traits := []rs.RoleTraitOption{
+		rs.WithRoleProfile(profile),
+	}
+
+	return rs.NewRoleResource(
+		role.Name,
+		&v2.ResourceType{
+			Id:          clusterRoleResourceTypeID,
+			DisplayName: "Cluster Role",
+			Traits: []v2.ResourceType_Trait{
+				v2.ResourceType_TRAIT_ROLE,
+			},
+		},
+		string(role.UID),
+		traits,
+	)
+}
+
+// convertV1ClusterRoleBindings2Resources (plural) convert cluster role bindings of Openshift to grants
+// of Baton SDK for a given entitlement, the subjects are resolved through `principals`.
+func convertV1ClusterRoleBindings2Resources(bindings []rbacv1.ClusterRoleBinding, entitlement *v2.Resource, principals *subjectIndex) []*v2.Grant {
+	var grts []*v2.Grant
+	for _, binding := range bindings {
+		grts = append(grts, convertV1ClusterRoleBinding2Resource(binding, entitlement, principals)...)
+	}
+	return grts
+}
+
+// convertV1ClusterRoleBinding2Resource (singular) convert a cluster role binding to a grant for each of
+// its subjects, subjects that weren't synced are skipped. use by `convertV1ClusterRoleBindings2Resources`.
+func convertV1ClusterRoleBinding2Resource(binding rbacv1.ClusterRoleBinding, entitlement *v2.Resource, principals *subjectIndex) []*v2.Grant {
+	var grts []*v2.Grant
+	for _, subject := range binding.Subjects {
+		principal, ok := principals.resolve(subject)
+		if !ok {
+			continue
+		}
+		grts = append(grts, newSubjectGrant(entitlement, "member", principal, bindingGrantOptions(binding.ObjectMeta)...))
+	}
+	return grts
+}
+
+// convertV1ClusterRoleRoleBindings2Resources (plural) convert role bindings of a cluster role to grants
+// of its entitlement in the namespace of each binding, the subjects are resolved through `principals`.
+func convertV1ClusterRoleRoleBindings2Resources(roleBindings []rbacv1.RoleBinding, entitlement *v2.Resource, principals *subjectIndex) []*v2.Grant {
+	var grts []*v2.Grant
+	for _, binding := range roleBindings {
+		grts = append(grts, convertV1ClusterRoleRoleBinding2Resource(binding, entitlement, principals)...)
+	}
+	return grts
+}
+
+// convertV1ClusterRoleRoleBinding2Resource (singular) convert a role binding of a cluster role to a
+// grant for each of its subjects. use by `convertV1ClusterRoleRoleBindings2Resources`.
+func convertV1ClusterRoleRoleBinding2Resource(roleBinding rbacv1.RoleBinding, entitlement *v2.Resource, principals *subjectIndex) []*v2.Grant {
+	var grts []*v2.Grant
+	for _, subject := range roleBinding.Subjects {
+		principal, ok := principals.resolve(subject)
+		if !ok {
+			continue
+		}
+		grts = append(grts, newSubjectGrant(entitlement, NamespacedMemberSlug(roleBinding.Namespace), principal, bindingGrantOptions(roleBinding.ObjectMeta)...))
+	}
+	return grts
+}
 
 // convertV1RoleBindings2Resources (plural) convert role bindings of Openshift to grants of Baton SDK.
-// for a given entitlement and a  list of user resources.
-func convertV1RoleBindings2Resources(roleBindings []rbacv1.RoleBinding, entitlement *v2.Resource, users []*v2.Resource) ([]*v2.Grant, error) {
+// for a given entitlement, the subjects are resolved through `principals`.
+func convertV1RoleBindings2Resources(roleBindings []rbacv1.RoleBinding, entitlement *v2.Resource, principals *subjectIndex) []*v2.Grant {
 	var grts []*v2.Grant
 	for _, binding := range roleBindings {
-		result, err := convertV1RoleBinding2Resource(binding, entitlement, users)
+		grts = append(grts, convertV1RoleBinding2Resource(binding, entitlement, principals)...)
+	}
+	return grts
+}
+
+// convertV1RoleBinding2Resource (singular) convert a role binding to a grant for each of its subjects,
+// subjects that weren't synced are skipped. use by `convertV1RoleBindings2Resources`.
+func convertV1RoleBinding2Resource(roleBinding rbacv1.RoleBinding, entitlement *v2.Resource, principals *subjectIndex) []*v2.Grant {
+	var grts []*v2.Grant
+	for _, subject := range roleBinding.Subjects {
+		principal, ok := principals.resolve(subject)
+		if !ok {
+			continue
+		}
+		grts = append(grts, newSubjectGrant(entitlement, "member", principal, bindingGrantOptions(roleBinding.ObjectMeta)...))
+	}
+	return grts
+}
+
+// newSubjectGrant grants an entitlement to a principal, grants to
+// groups are expanded to their members.
+func newSubjectGrant(entitlement *v2.Resource, slug string, principal *v2.ResourceId, opts ...grant.GrantOption) *v2.Grant {
+	if principal.ResourceType == groupResourceTypeID {
+		opts = append(opts, grant.WithAnnotation(&v2.GrantExpandable{
+			EntitlementIds: []string{ent.NewEntitlementID(&v2.Resource{Id: principal}, "member")},
+		}))
+	}
+	return grant.NewGrant(entitlement, slug, principal, opts...)
+}
+
+// convertV1ServiceAccounts2Resources (plural) convert service accounts of Openshift to resources of Baton SDK,
+// only the metadata of the service accounts is needed.
+func convertV1ServiceAccounts2Resources(serviceAccounts []metav1.PartialObjectMetadata) ([]*v2.Resource, error) {
+	var rsc []*v2.Resource
+	for _, sa := range serviceAccounts {
+		result, err := convertV1ServiceAccount2Resource(sa)
 		if err != nil {
-			if errors.Is(err, errRoleNotGranted) {
-				// skip
-				continue
-			}
-			return nil, fmt.Errorf("binding %s - resource %s, error: %w", binding.RoleRef.Name, entitlement.DisplayName, err)
+			return nil, fmt.Errorf("resource %s, error: %w", sa.UID, err)
 		}
-		grts = append(grts, result)
+		rsc = append(rsc, result)
 	}
-	return grts, nil
+
+	return rsc, nil
 }
 
-// convertV1RoleBinding2Resource (singular) convert a role binding, for a given entitlement and a list of user resources.
-// use by `convertV1RoleBindings2Resources`.
-func convertV1RoleBinding2Resource(roleBinding rbacv1.RoleBinding, entitlement *v2.Resource, users []*v2.Resource) (*v2.Grant, error) {
-	if len(roleBinding.Subjects) == 0 {
-		return nil, errRoleNotGranted
+// convertV1ServiceAccount2Resource (singular) convert a service account to a resource, use by
+// `convertV1ServiceAccounts2Resources`.
+func convertV1ServiceAccount2Resource(sa metav1.PartialObjectMetadata) (*v2.Resource, error) {
+	annos := annotations.Annotations{}
+	annos.Update(&v2.SkipEntitlementsAndGrants{})
+
+	profile := map[string]interface{}{
+		"name":      sa.Name,
+		"namespace": sa.Namespace,
 	}
 
-	splittedRoleBinding := strings.Split(roleBinding.Name, "-")
-	if len(splittedRoleBinding) <= 1 {
-		return nil, errRoleNotGranted
+	traits := []rs.UserTraitOption{
+		rs.WithUserProfile(profile),
+		rs.WithAccountType(v2.UserTrait_ACCOUNT_TYPE_SERVICE),
+		// the username used by the service account to authenticate
+		rs.WithUserLogin(fmt.Sprintf("system:serviceaccount:%s:%s", sa.Namespace, sa.Name)),
+		rs.WithCreatedAt(sa.CreationTimestamp.Time),
 	}
-	if strings.Contains(entitlement.DisplayName, splittedRoleBinding[1]) {
-		for _, user := range users {
-			if user.DisplayName == roleBinding.Subjects[0].Name {
-				return grant.NewGrant(
-					entitlement,
-					"member",
-					user.Id,
-				), nil
-			}
+
+	return rs.NewUserResource(
+		namespacedName(sa.ObjectMeta),
+		&v2.ResourceType{
+			Id:          serviceAccountResourceTypeID,
+			DisplayName: "Service Account",
+			Traits: []v2.ResourceType_Trait{
+				v2.ResourceType_TRAIT_USER,
+			},
+			Annotations: annos,
+		},
+		string(sa.UID),
+		traits,
+	)
+}
+
+// convertNamespaces2Resources (plural) convert the names of namespaces to resources of Baton SDK.
+func convertNamespaces2Resources(names []string) ([]*v2.Resource, error) {
+	var rsc []*v2.Resource
+	for _, name := range names {
+		result, err := convertNamespace2Resource(name)
+		if err != nil {
+			return nil, fmt.Errorf("resource %s, error: %w", name, err)
 		}
+		rsc = append(rsc, result)
 	}
 
-	return nil, errRoleNotGranted
+	return rsc, nil
+}
+
+// convertNamespace2Resource (singular) convert a namespace to a resource, use by
+// `convertNamespaces2Resources`. namespaces are identified by their name, so
+// naming them in the configuration doesn't require to read them.
+func convertNamespace2Resource(name string) (*v2.Resource, error) {
+	annos := annotations.Annotations{}
+	annos.Update(&v2.SkipEntitlementsAndGrants{})
+
+	return rs.NewResource(
+		name,
+		&v2.ResourceType{
+			Id:          namespaceResourceTypeID,
+			DisplayName: "Namespace",
+			Annotations: annos,
+		},
+		name,
+	)
 }
 
 // convertV1Groups2Resources (plural) convert a list of groups of Openshift to resources of Baton SDK.
@@ -176,6 +470,9 @@ func convertV1Group2Resource(group v1.Group) (*v2.Resource, error) {
 		"generate_name": group.GetGenerateName(),
 		"created_at":    group.CreationTimestamp.Format(time.RFC3339),
 	}
+	for key, value := range ldapProfile(group) {
+		profile[key] = value
+	}
 
 	traits := []rs.GroupTraitOption{
 		rs.WithGroupProfile(profile),
@@ -184,7 +481,7 @@ func convertV1Group2Resource(group v1.Group) (*v2.Resource, error) {
 	return rs.NewGroupResource(
 		group.GetName(),
 		&v2.ResourceType{
-			Id:          "group",
+			Id:          groupResourceTypeID,
 			DisplayName: "Team",
 			Traits: []v2.ResourceType_Trait{
 				v2.ResourceType_TRAIT_GROUP,
@@ -194,3 +491,75 @@ func convertV1Group2Resource(group v1.Group) (*v2.Resource, error) {
 		traits,
 	)
 }
+
+// convertV1SCCs2Resources (plural) convert security context constraints of Openshift to resources
+// of Baton SDK.
+func convertV1SCCs2Resources(sccs []securityv1.SecurityContextConstraints) ([]*v2.Resource, error) {
+	var rsc []*v2.Resource
+	for _, scc := range sccs {
+		result, err := convertV1SCC2Resource(scc)
+		if err != nil {
+			return nil, fmt.Errorf("resource %s, error: %w", scc.UID, err)
+		}
+		rsc = append(rsc, result)
+	}
+
+	return rsc, nil
+}
+
+// convertV1SCC2Resource (singular) convert a security context constraints to a resource, use by
+// `convertV1SCCs2Resources`.
+func convertV1SCC2Resource(scc securityv1.SecurityContextConstraints) (*v2.Resource, error) {
+	var opts []rs.ResourceOption
+	if description := scc.Annotations[descriptionAnnotation]; description != "" {
+		opts = append(opts, rs.WithDescription(description))
+	}
+
+	return rs.NewResource(
+		scc.Name,
+		&v2.ResourceType{
+			Id:          sccResourceTypeID,
+			DisplayName: "Security Context Constraints",
+		},
+		string(scc.UID),
+		opts...,
+	)
+}
+
+// convertV1OAuthClients2Resources (plural) convert OAuth clients of Openshift to resources of Baton SDK.
+func convertV1OAuthClients2Resources(clients []oauthv1.OAuthClient) ([]*v2.Resource, error) {
+	var rsc []*v2.Resource
+	for _, client := range clients {
+		result, err := convertV1OAuthClient2Resource(client)
+		if err != nil {
+			return nil, fmt.Errorf("resource %s, error: %w", client.UID, err)
+		}
+		rsc = append(rsc, result)
+	}
+
+	return rsc, nil
+}
+
+// convertV1OAuthClient2Resource (singular) convert an OAuth client to a resource, its secrets are
+// left out. use by `convertV1OAuthClients2Resources`.
+func convertV1OAuthClient2Resource(client oauthv1.OAuthClient) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"name":          client.Name,
+		"created_at":    client.CreationTimestamp.Format(time.RFC3339),
+		"grant_method":  string(client.GrantMethod),
+		"redirect_uris": stringsProfile(client.RedirectURIs),
+	}
+
+	return rs.NewAppResource(
+		client.Name,
+		&v2.ResourceType{
+			Id:          oauthClientResourceTypeID,
+			DisplayName: "OAuth Client",
+			Traits: []v2.ResourceType_Trait{
+				v2.ResourceType_TRAIT_APP,
+			},
+		},
+		string(client.UID),
+		[]rs.AppTraitOption{rs.WithAppProfile(profile)},
+	)
+}
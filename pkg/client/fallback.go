@@ -0,0 +1,183 @@
+package client
+
+// fallback.go derives the users and groups from the subjects of the
+// role bindings and cluster role bindings, on clusters that don't serve
+// the user API of OpenShift: plain Kubernetes, or OpenShift with an
+// external OIDC provider, where users and groups only exist in the
+// tokens of the identity provider.
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	v1 "github.com/openshift/api/user/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// hasUserAPI reports whether the API server serves the user API of
+// OpenShift.
+func (c *Client) hasUserAPI(ctx context.Context) (bool, error) {
+	return c.serves(ctx, v1.GroupVersion, "users and groups are derived from the subjects of the role bindings")
+}
+
+// serves reports whether the API server serves a group version, it is
+// discovered once per client. `fallback` tells what the connector does
+// without it.
+func (c *Client) serves(ctx context.Context, gv schema.GroupVersion, fallback string) (bool, error) {
+	c.servedMu.Lock()
+	defer c.servedMu.Unlock()
+	if served, ok := c.served[gv]; ok {
+		return served, nil
+	}
+
+	_, err := withRetry(ctx, func() (*metav1.APIResourceList, error) {
+		return c.k8sClient.Discovery().ServerResourcesForGroupVersion(gv.String())
+	})
+	served := err == nil
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("unable to discover %s, error: %w", gv, err)
+		}
+		ctxzap.Extract(ctx).Info("the API server doesn't serve " + gv.String() + ", " + fallback)
+	}
+	if c.served == nil {
+		c.served = map[schema.GroupVersion]bool{}
+	}
+	c.served[gv] = served
+
+	return served, nil
+}
+
+func (c *Client) listClusterRoleBindings(ctx context.Context, opts metav1.ListOptions) ([]rbacv1.ClusterRoleBinding, string, error) {
+	if c.cache != nil {
+		return cachedList[rbacv1.ClusterRoleBinding](c.cache, clusterRoleBindingsResource, "")(ctx, opts)
+	}
+	list, err := c.k8sClient.RbacV1().ClusterRoleBindings().List(ctx, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	return list.Items, list.Continue, nil
+}
+
+// bindingNamespaces returns the namespaces to list the role bindings
+// of. The role bindings of every namespace are listed at once, unless
+// some namespaces are filtered out.
+func (c *Client) bindingNamespaces(ctx context.Context) ([]string, error) {
+	if slices.Contains(c.namespaces, AllNamespaces) && c.filters.Namespaces == nil {
+		return []string{metav1.NamespaceAll}, nil
+	}
+	return c.namespaceNames(ctx)
+}
+
+// subjectNames returns the sorted names of the subjects of a kind that
+// are bound by the cluster role bindings, or by the role bindings of
+// the synced namespaces.
+func (c *Client) subjectNames(ctx context.Context, kind string) ([]string, error) {
+	clusterBindings, err := listAll(ctx, c, c.listClusterRoleBindings)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list cluster role bindings, error: %w", err)
+	}
+	var subjects []rbacv1.Subject
+	for _, binding := range clusterBindings {
+		subjects = append(subjects, binding.Subjects...)
+	}
+
+	namespaces, err := c.bindingNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, namespace := range namespaces {
+		bindings, err := listAll(ctx, c, c.listRoleBindings(namespace))
+		if err != nil {
+			return nil, fmt.Errorf("unable to list role bindings, namespace %s, error: %w", namespace, err)
+		}
+		for _, binding := range bindings {
+			subjects = append(subjects, binding.Subjects...)
+		}
+	}
+
+	var names []string
+	for _, subject := range subjects {
+		if subject.Kind == kind {
+			names = append(names, subject.Name)
+		}
+	}
+	sort.Strings(names)
+
+	return slices.Compact(names), nil
+}
+
+// listDerivedUsers list the users bound by role bindings, all at once.
+func (c *Client) listDerivedUsers(ctx context.Context) ([]*v2.Resource, error) {
+	names, err := c.subjectNames(ctx, rbacv1.UserKind)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []*v2.Resource
+	for _, name := range names {
+		if !c.filters.Users.Match(name, nil) {
+			continue
+		}
+		user, err := convertExternalUser2Resource(name)
+		if err != nil {
+			return nil, fmt.Errorf("external user %s, error: %w", name, err)
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// listDerivedGroups list the groups bound by role bindings, all at once.
+func (c *Client) listDerivedGroups(ctx context.Context) ([]*v2.Resource, error) {
+	names, err := c.subjectNames(ctx, rbacv1.GroupKind)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []*v2.Resource
+	for _, name := range names {
+		// the virtual groups are listed on their own
+		if !c.filters.Groups.Match(name, nil) || slices.Contains(virtualGroups, name) {
+			continue
+		}
+		group, err := convertExternalGroup2Resource(name)
+		if err != nil {
+			return nil, fmt.Errorf("external group %s, error: %w", name, err)
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+// derivedSubjectIndex indexes the users and groups of the given
+// subjects, without User and Group objects they are known by name.
+func (c *Client) derivedSubjectIndex(subjects []rbacv1.Subject) *subjectIndex {
+	index := &subjectIndex{
+		users:  map[string]*v2.ResourceId{},
+		groups: map[string]*v2.ResourceId{},
+	}
+	for _, subject := range subjects {
+		switch subject.Kind {
+		case rbacv1.UserKind:
+			if c.filters.Users.Match(subject.Name, nil) {
+				index.users[subject.Name] = c.scopeID(externalUserResourceID(subject.Name))
+			}
+		case rbacv1.GroupKind:
+			if c.filters.Groups.Match(subject.Name, nil) {
+				index.groups[subject.Name] = c.scopeID(externalGroupResourceID(subject.Name))
+			}
+		}
+	}
+
+	return index
+}
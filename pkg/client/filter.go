@@ -0,0 +1,156 @@
+package client
+
+// filter.go selects the objects synced by the connector, so the noise
+// of the objects a cluster creates for itself (system users, operator
+// namespaces, etc.) can be left out.
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// labelRulePrefix marks the rules that are label selectors.
+const labelRulePrefix = "label:"
+
+// Exclude rules of the objects OpenShift creates for itself. The virtual
+// groups of every user (system:authenticated, etc.) are kept, they are
+// the access everyone gets.
+var (
+	OpenShiftExcludedUsers           = []string{`system:.*`}
+	OpenShiftExcludedGroups          = []string{`system:serviceaccounts(:.*)?`, `system:(nodes|masters|cluster-admins|cluster-readers|bootstrappers(:.*)?)`}
+	OpenShiftExcludedNamespaces      = []string{`openshift`, `openshift-.*`, `kube-.*`}
+	OpenShiftExcludedRoles           = []string{`system:.*`}
+	OpenShiftExcludedServiceAccounts = []string{`builder`, `deployer`}
+)
+
+// Filter selects objects by name or by labels. An object is selected
+// when it matches any of the include rules (or there are none) and
+// none of the exclude rules. A nil Filter selects everything.
+type Filter struct {
+	include []rule
+	exclude []rule
+}
+
+// rule matches either the name or the labels of an object.
+type rule struct {
+	name  *regexp.Regexp
+	label labels.Selector
+}
+
+// Filters are the filters of each kind of object, nil ones select
+// every object of their kind.
+type Filters struct {
+	Users           *Filter
+	Groups          *Filter
+	Namespaces      *Filter
+	Roles           *Filter
+	ServiceAccounts *Filter
+}
+
+// WithFilters sets the filters of the synced objects.
+func WithFilters(filters Filters) Option {
+	return func(c *Client) {
+		c.filters = filters
+	}
+}
+
+// NewFilter parses include and exclude rules. A rule is a regular
+// expression that matches the whole name of the object, or a label
+// selector prefixed with `label:` (e.g. `label:app=web`). Without any
+// rule the filter is nil.
+func NewFilter(include, exclude []string) (*Filter, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+
+	f := &Filter{}
+	var err error
+	if f.include, err = parseRules(include); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = parseRules(exclude); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func parseRules(rules []string) ([]rule, error) {
+	var parsed []rule
+	for _, r := range rules {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		if selector, ok := strings.CutPrefix(r, labelRulePrefix); ok {
+			s, err := labels.Parse(selector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid label selector %q: %w", selector, err)
+			}
+			parsed = append(parsed, rule{label: s})
+			continue
+		}
+		re, err := regexp.Compile("^(?:" + r + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", r, err)
+		}
+		parsed = append(parsed, rule{name: re})
+	}
+
+	return parsed, nil
+}
+
+func (r rule) match(name string, lbls map[string]string) bool {
+	if r.label != nil {
+		return r.label.Matches(labels.Set(lbls))
+	}
+	return r.name.MatchString(name)
+}
+
+// Match reports whether the object with the given name and labels is
+// selected, objects only known by name have no labels.
+func (f *Filter) Match(name string, lbls map[string]string) bool {
+	if f == nil {
+		return true
+	}
+
+	included := len(f.include) == 0
+	for _, r := range f.include {
+		if r.match(name, lbls) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	for _, r := range f.exclude {
+		if r.match(name, lbls) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filterObjects keeps the selected objects.
+func filterObjects[T any, PT interface {
+	*T
+	metav1.Object
+}](f *Filter, items []T) []T {
+	if f == nil {
+		return items
+	}
+	var selected []T
+	for i := range items {
+		obj := PT(&items[i])
+		if f.Match(obj.GetName(), obj.GetLabels()) {
+			selected = append(selected, items[i])
+		}
+	}
+	return selected
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "no rules", wantNil: true},
+		{name: "blank rules", include: []string{" "}},
+		{name: "name rules", include: []string{`team-.*`}, exclude: []string{`team-legacy`}},
+		{name: "label rule", include: []string{"label:app=web"}},
+		{name: "invalid regular expression", include: []string{`team-(`}, wantErr: true},
+		{name: "invalid label selector", exclude: []string{"label:app in (web"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFilter(tt.include, tt.exclude)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantNil, f == nil)
+		})
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		object  string
+		labels  map[string]string
+		want    bool
+	}{
+		{name: "no rules", object: "anything", want: true},
+		{name: "included", include: []string{`team-.*`}, object: "team-a", want: true},
+		{name: "not included", include: []string{`team-.*`}, object: "ops", want: false},
+		{name: "rules match the whole name", include: []string{`team`}, object: "team-a", want: false},
+		{name: "excluded", exclude: []string{`system:.*`}, object: "system:admin", want: false},
+		{name: "exclusion wins over inclusion", include: []string{`team-.*`}, exclude: []string{`team-legacy`}, object: "team-legacy", want: false},
+		{name: "included by label", include: []string{"label:app=web"}, object: "web", labels: map[string]string{"app": "web"}, want: true},
+		{name: "excluded by label", exclude: []string{"label:tier=system"}, object: "kube", labels: map[string]string{"tier": "system"}, want: false},
+		{name: "label rules don't match objects without labels", include: []string{"label:app=web"}, object: "web", want: false},
+		{name: "any inclusion", include: []string{"label:app=web", `ops`}, object: "ops", want: true},
+		{name: "default namespaces", exclude: OpenShiftExcludedNamespaces, object: "openshift-monitoring", want: false},
+		{name: "default groups keep the virtual groups", exclude: OpenShiftExcludedGroups, object: "system:authenticated", want: true},
+		{name: "default groups", exclude: OpenShiftExcludedGroups, object: "system:serviceaccounts:team-a", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFilter(tt.include, tt.exclude)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, f.Match(tt.object, tt.labels))
+		})
+	}
+}
+
+func TestFilterObjects(t *testing.T) {
+	role := func(name string, lbls map[string]string) rbacv1.Role {
+		return rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: lbls}}
+	}
+	roles := []rbacv1.Role{
+		role("admin", nil),
+		role("system:deployer", nil),
+		role("web-admin", map[string]string{"app": "web"}),
+	}
+	excludeSystem, err := NewFilter(nil, OpenShiftExcludedRoles)
+	require.NoError(t, err)
+	onlyWeb, err := NewFilter([]string{"label:app=web"}, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		filter *Filter
+		want   []string
+	}{
+		{name: "nil filter", want: []string{"admin", "system:deployer", "web-admin"}},
+		{name: "excluded names", filter: excludeSystem, want: []string{"admin", "web-admin"}},
+		{name: "included labels", filter: onlyWeb, want: []string{"web-admin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, r := range filterObjects(tt.filter, roles) {
+				names = append(names, r.Name)
+			}
+			require.Equal(t, tt.want, names)
+		})
+	}
+}
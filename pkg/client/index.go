@@ -0,0 +1,88 @@
+package client
+
+// index.go keeps the indexes built from whole listings (e.g. every
+// user by name) for a while. The SDK asks for the grants of the
+// resources one after the other, rebuilding the indexes each time would
+// list the whole cluster again for every resource. The indexes aren't
+// tied to syncs: one built near the end of a sync is reused by the next
+// one until stale, and a sync lasting longer than the TTL rebuilds them.
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultIndexTTL is how long an index is reused before it is built
+// again, unless set with WithIndexTTL.
+const DefaultIndexTTL = 5 * time.Minute
+
+// WithIndexTTL sets how long the indexes built from whole listings are
+// reused. The syncs of large clusters lasting longer than the TTL list
+// them again, a TTL longer than a sync avoids it at the cost of staler
+// grants.
+func WithIndexTTL(ttl time.Duration) Option {
+	return func(c *Client) {
+		if ttl > 0 {
+			c.indexTTL = ttl
+		}
+	}
+}
+
+// staleAfter is how long the indexes of the client are reused.
+func (c *Client) staleAfter() time.Duration {
+	if c.indexTTL > 0 {
+		return c.indexTTL
+	}
+	return DefaultIndexTTL
+}
+
+// ttlIndex is an index built on first use and rebuilt once stale. The
+// index is shared by the callers, they must not modify it.
+type ttlIndex[T any] struct {
+	mu    sync.Mutex
+	index T
+	at    time.Time
+	built bool
+}
+
+// get returns the index, building it with `build` when missing or older
+// than `ttl`.
+func (i *ttlIndex[T]) get(ttl time.Duration, build func() (T, error)) (T, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.built && time.Since(i.at) <= ttl {
+		return i.index, nil
+	}
+
+	index, err := build()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	i.index, i.at, i.built = index, time.Now(), true
+
+	return index, nil
+}
+
+// ttlIndexes are ttlIndexes by key, e.g. by namespace.
+type ttlIndexes[K comparable, T any] struct {
+	mu      sync.Mutex
+	indexes map[K]*ttlIndex[T]
+}
+
+// get returns the index of `key`, building it with `build` when missing
+// or older than `ttl`.
+func (i *ttlIndexes[K, T]) get(key K, ttl time.Duration, build func() (T, error)) (T, error) {
+	i.mu.Lock()
+	index, ok := i.indexes[key]
+	if !ok {
+		if i.indexes == nil {
+			i.indexes = map[K]*ttlIndex[T]{}
+		}
+		index = &ttlIndex[T]{}
+		i.indexes[key] = index
+	}
+	i.mu.Unlock()
+
+	return index.get(ttl, build)
+}
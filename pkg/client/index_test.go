@@ -0,0 +1,64 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTTLIndex(t *testing.T) {
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		// errs are the errors of the successive calls, every failed
+		// call builds the index
+		errs       []error
+		want       []int
+		wantBuilds int
+	}{
+		{name: "reused", ttl: time.Hour, errs: []error{nil, nil, nil}, want: []int{1, 1, 1}, wantBuilds: 1},
+		{name: "stale", ttl: time.Nanosecond, errs: []error{nil, nil, nil}, want: []int{1, 2, 3}, wantBuilds: 3},
+		{name: "failed builds aren't kept", ttl: time.Hour, errs: []error{errors.New("forbidden"), nil, nil}, want: []int{0, 2, 2}, wantBuilds: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var index ttlIndex[int]
+			builds := 0
+			for i, wantErr := range tt.errs {
+				got, err := index.get(tt.ttl, func() (int, error) {
+					builds++
+					return builds, tt.errs[builds-1]
+				})
+				if wantErr != nil {
+					require.ErrorIs(t, err, wantErr)
+				} else {
+					require.NoError(t, err)
+				}
+				require.Equal(t, tt.want[i], got)
+				time.Sleep(time.Millisecond)
+			}
+			require.Equal(t, tt.wantBuilds, builds)
+		})
+	}
+}
+
+func TestWithIndexTTL(t *testing.T) {
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{name: "set", ttl: time.Hour, want: time.Hour},
+		{name: "unset", want: DefaultIndexTTL},
+		{name: "negative", ttl: -time.Second, want: DefaultIndexTTL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{}
+			WithIndexTTL(tt.ttl)(c)
+			require.Equal(t, tt.want, c.staleAfter())
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package client
+
+// ldap.go recognizes the groups synced from LDAP by `oc adm groups sync`.
+// Their members are whatever the directory says at the last sync, so
+// their membership is managed in the directory, not on the cluster.
+
+import (
+	v1 "github.com/openshift/api/user/v1"
+)
+
+const (
+	// ldapURLAnnotation is the LDAP server a group was synced from.
+	ldapURLAnnotation = "openshift.io/ldap.url"
+	// ldapUIDAnnotation is the unique identifier of the group on the
+	// LDAP server.
+	ldapUIDAnnotation = "openshift.io/ldap.uid"
+	// ldapSyncTimeAnnotation is when the group was last synced.
+	ldapSyncTimeAnnotation = "openshift.io/ldap.sync-time"
+)
+
+// isLDAPGroup reports whether a group is synced from LDAP.
+func isLDAPGroup(group v1.Group) bool {
+	return group.Annotations[ldapURLAnnotation] != "" || group.Annotations[ldapUIDAnnotation] != ""
+}
+
+// ldapProfile returns the provenance of a group synced from LDAP, as
+// values of its profile.
+func ldapProfile(group v1.Group) map[string]interface{} {
+	profile := map[string]interface{}{
+		"ldap_synced": isLDAPGroup(group),
+	}
+	if !isLDAPGroup(group) {
+		return profile
+	}
+	profile["ldap_url"] = group.Annotations[ldapURLAnnotation]
+	profile["ldap_uid"] = group.Annotations[ldapUIDAnnotation]
+	profile["ldap_sync_time"] = group.Annotations[ldapSyncTimeAnnotation]
+	return profile
+}
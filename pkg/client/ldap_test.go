@@ -0,0 +1,62 @@
+package client
+
+import (
+	"testing"
+
+	v1 "github.com/openshift/api/user/v1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLDAPProfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantLDAP    bool
+		want        map[string]interface{}
+	}{
+		{
+			name:        "local group",
+			annotations: map[string]string{"description": "admins"},
+			want:        map[string]interface{}{"ldap_synced": false},
+		},
+		{
+			name: "synced group",
+			annotations: map[string]string{
+				ldapURLAnnotation:      "ldap.example.com:389",
+				ldapUIDAnnotation:      "cn=admins,ou=groups,dc=example,dc=com",
+				ldapSyncTimeAnnotation: "2024-01-02T03:04:05Z",
+			},
+			wantLDAP: true,
+			want: map[string]interface{}{
+				"ldap_synced":    true,
+				"ldap_url":       "ldap.example.com:389",
+				"ldap_uid":       "cn=admins,ou=groups,dc=example,dc=com",
+				"ldap_sync_time": "2024-01-02T03:04:05Z",
+			},
+		},
+		{
+			name:        "only the UID of the group",
+			annotations: map[string]string{ldapUIDAnnotation: "cn=admins,ou=groups,dc=example,dc=com"},
+			wantLDAP:    true,
+			want: map[string]interface{}{
+				"ldap_synced":    true,
+				"ldap_url":       "",
+				"ldap_uid":       "cn=admins,ou=groups,dc=example,dc=com",
+				"ldap_sync_time": "",
+			},
+		},
+		{
+			name:        "empty annotations",
+			annotations: map[string]string{ldapURLAnnotation: "", ldapUIDAnnotation: ""},
+			want:        map[string]interface{}{"ldap_synced": false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := v1.Group{ObjectMeta: metav1.ObjectMeta{Name: "admins", Annotations: tt.annotations}}
+			require.Equal(t, tt.wantLDAP, isLDAPGroup(group))
+			require.Equal(t, tt.want, ldapProfile(group))
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package client
+
+// logins.go derives when users last logged in, and how many sessions
+// they have, from the OAuth access tokens OpenShift issues at each
+// login. The tokens of every user are listed at once, only their user
+// and when they were issued are kept. OpenShift deletes the tokens once
+// they expire, so the tokens left are the active sessions.
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	oauthv1 "github.com/openshift/api/oauth/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var oauthAccessTokensResource = oauthv1.GroupVersion.WithResource("oauthaccesstokens")
+
+// login is what the access tokens of a user tell about its logins.
+type login struct {
+	last     time.Time
+	sessions int
+}
+
+// WithLastLogin sets the last login and active sessions of the users
+// from the OAuth access tokens.
+func WithLastLogin(enabled bool) Option {
+	return func(c *Client) {
+		c.lastLogin = enabled
+	}
+}
+
+// hasOAuthAPI reports whether the API server serves the OAuth API of
+// OpenShift.
+func (c *Client) hasOAuthAPI(ctx context.Context) (bool, error) {
+	return c.serves(ctx, oauthv1.GroupVersion, "the last logins of the users are unknown")
+}
+
+// issuedToken is what an access token tells about a login, the rest of
+// the token is dropped as soon as its page is listed.
+type issuedToken struct {
+	userName string
+	issued   time.Time
+}
+
+// listOAuthAccessTokens lists the access tokens of every user.
+func (c *Client) listOAuthAccessTokens(ctx context.Context, opts metav1.ListOptions) ([]issuedToken, string, error) {
+	list, err := c.oauthClient.OAuthAccessTokens().List(ctx, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	tokens := make([]issuedToken, 0, len(list.Items))
+	for _, token := range list.Items {
+		tokens = append(tokens, issuedToken{userName: token.UserName, issued: token.CreationTimestamp.Time})
+	}
+	return tokens, list.Continue, nil
+}
+
+// loginsOf returns the logins told by the access tokens, by user. The
+// last login is when the latest token was issued, every token is a
+// session since the expired ones are deleted.
+func loginsOf(tokens []issuedToken) map[string]login {
+	logins := map[string]login{}
+	for _, token := range tokens {
+		l := logins[token.userName]
+		l.sessions++
+		if token.issued.After(l.last) {
+			l.last = token.issued
+		}
+		logins[token.userName] = l
+	}
+	return logins
+}
+
+// loginIndex maps the names of the users to their logins, it is reused
+// until stale.
+func (c *Client) loginIndex(ctx context.Context) (map[string]login, error) {
+	return c.logins.get(c.staleAfter(), func() (map[string]login, error) {
+		tokens, err := listAll(ctx, c, c.listOAuthAccessTokens)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list OAuth access tokens, error: %w", err)
+		}
+		return loginsOf(tokens), nil
+	})
+}
+
+// withLogins sets the last login and the active sessions of users, when
+// enabled and the OAuth API is served.
+func (c *Client) withLogins(ctx context.Context, users []*v2.Resource) ([]*v2.Resource, error) {
+	if !c.lastLogin || len(users) == 0 {
+		return users, nil
+	}
+	served, err := c.hasOAuthAPI(ctx)
+	if err != nil || !served {
+		return users, err
+	}
+	logins, err := c.loginIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		trait, err := rs.GetUserTrait(user)
+		if err != nil {
+			return nil, err
+		}
+		l := logins[user.DisplayName]
+		if trait.Profile == nil {
+			trait.Profile = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+		}
+		trait.Profile.Fields["active_sessions"] = structpb.NewNumberValue(float64(l.sessions))
+		if !l.last.IsZero() {
+			trait.LastLogin = timestamppb.New(l.last)
+		}
+		annos := annotations.Annotations(user.Annotations)
+		annos.Update(trait)
+		user.Annotations = annos
+	}
+
+	return users, nil
+}
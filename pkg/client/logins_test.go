@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	oauthv1 "github.com/openshift/api/oauth/v1"
+	oauthfake "github.com/openshift/client-go/oauth/clientset/versioned/fake"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// accessToken is an access token of a user issued at `issued`.
+func accessToken(name, userName string, issued time.Time) *oauthv1.OAuthAccessToken {
+	return &oauthv1.OAuthAccessToken{
+		ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(issued)},
+		UserName:   userName,
+	}
+}
+
+func TestLoginsOf(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	tests := []struct {
+		name   string
+		tokens []issuedToken
+		want   map[string]login
+	}{
+		{name: "no tokens", want: map[string]login{}},
+		{
+			name:   "single token",
+			tokens: []issuedToken{{userName: "alice", issued: now.Add(-time.Hour)}},
+			want:   map[string]login{"alice": {last: now.Add(-time.Hour), sessions: 1}},
+		},
+		{
+			name: "latest token of many",
+			tokens: []issuedToken{
+				{userName: "alice", issued: now.Add(-time.Hour)},
+				{userName: "bob", issued: now.Add(-time.Hour)},
+				{userName: "alice", issued: now.Add(-time.Minute)},
+				{userName: "alice", issued: now.Add(-24 * time.Hour)},
+			},
+			want: map[string]login{
+				"alice": {last: now.Add(-time.Minute), sessions: 3},
+				"bob":   {last: now.Add(-time.Hour), sessions: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, loginsOf(tt.tokens))
+		})
+	}
+}
+
+func TestWithLogins(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	tokens := []runtime.Object{
+		accessToken("sha256~a", "alice", now.Add(-time.Hour)),
+		accessToken("sha256~b", "alice", now.Add(-time.Minute)),
+		accessToken("sha256~c", "bob", now.Add(-24*time.Hour)),
+		// users that aren't synced
+		accessToken("sha256~d", "dave", now),
+	}
+
+	tests := []struct {
+		name         string
+		lastLogin    bool
+		wantSessions map[string]float64
+		wantLast     map[string]time.Time
+		wantLists    int
+	}{
+		{name: "disabled"},
+		{
+			name:         "enabled",
+			lastLogin:    true,
+			wantSessions: map[string]float64{"alice": 2, "bob": 1, "carol": 0},
+			wantLast:     map[string]time.Time{"alice": now.Add(-time.Minute), "bob": now.Add(-24 * time.Hour)},
+			wantLists:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oauth := oauthfake.NewSimpleClientset(tokens...)
+			c := servedClient(oauthv1.GroupVersion)
+			c.oauthClient, c.lastLogin = oauth.OauthV1(), tt.lastLogin
+
+			// the users are set a page at a time, the tokens are listed
+			// once for every page
+			for _, page := range [][]string{{"alice", "bob"}, {"carol"}} {
+				var users []metav1.PartialObjectMetadata
+				for _, name := range page {
+					users = append(users, *partialObject(usersResource, "User", "", name, nil))
+				}
+				resources, err := convertV1Users2Resources(users)
+				require.NoError(t, err)
+
+				resources, err = c.withLogins(context.Background(), resources)
+				require.NoError(t, err)
+				for _, user := range resources {
+					trait, err := rs.GetUserTrait(user)
+					require.NoError(t, err)
+					sessions, ok := trait.Profile.GetFields()["active_sessions"]
+					if tt.wantSessions == nil {
+						require.False(t, ok)
+						require.Nil(t, trait.LastLogin)
+						continue
+					}
+					require.Equal(t, tt.wantSessions[user.DisplayName], sessions.GetNumberValue())
+					if last, ok := tt.wantLast[user.DisplayName]; ok {
+						require.True(t, last.Equal(trait.LastLogin.AsTime()))
+					} else {
+						require.Nil(t, trait.LastLogin)
+					}
+				}
+			}
+
+			var lists int
+			for _, action := range oauth.Actions() {
+				if action.Matches("list", "oauthaccesstokens") {
+					lists++
+				}
+			}
+			require.Equal(t, tt.wantLists, lists)
+		})
+	}
+}
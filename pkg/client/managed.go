@@ -0,0 +1,93 @@
+package client
+
+// managed.go recognizes the bindings the platform reconciles: the
+// default bindings Kubernetes keeps up to date, and those owned by
+// operators (e.g. OLM). Revoking their grants is undone by the next
+// reconciliation, so they are marked immutable with where they come from.
+
+import (
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	"google.golang.org/protobuf/types/known/structpb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// autoupdateKey marks the default bindings that the API server
+	// reconciles at startup.
+	autoupdateKey = "rbac.authorization.kubernetes.io/autoupdate"
+	// olmLabelPrefix prefixes the labels OLM sets on what it manages.
+	olmLabelPrefix = "olm."
+	// olmOwnerLabel, olmOwnerKindLabel and olmOwnerNamespaceLabel tell
+	// which object of OLM (e.g. a ClusterServiceVersion) owns a binding.
+	olmOwnerLabel          = "olm.owner"
+	olmOwnerKindLabel      = "olm.owner.kind"
+	olmOwnerNamespaceLabel = "olm.owner.namespace"
+)
+
+// managedBinding reports whether a binding is reconciled by the platform.
+func managedBinding(binding metav1.ObjectMeta) bool {
+	if binding.Annotations[autoupdateKey] == "true" || binding.Labels[autoupdateKey] == "true" {
+		return true
+	}
+	if len(binding.OwnerReferences) > 0 {
+		return true
+	}
+	for key := range binding.Labels {
+		if strings.HasPrefix(key, olmLabelPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// bindingOwner describes who reconciles a binding, it is empty when
+// only the autoupdate marker tells.
+func bindingOwner(binding metav1.ObjectMeta) string {
+	for _, ref := range binding.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller {
+			return ref.Kind + "/" + ref.Name
+		}
+	}
+	if len(binding.OwnerReferences) > 0 {
+		ref := binding.OwnerReferences[0]
+		return ref.Kind + "/" + ref.Name
+	}
+	if owner := binding.Labels[olmOwnerLabel]; owner != "" {
+		if namespace := binding.Labels[olmOwnerNamespaceLabel]; namespace != "" {
+			owner = namespace + "/" + owner
+		}
+		if kind := binding.Labels[olmOwnerKindLabel]; kind != "" {
+			owner = kind + "/" + owner
+		}
+		return owner
+	}
+	return ""
+}
+
+// bindingGrantOptions marks the grants of a binding reconciled by the
+// platform immutable, with the binding and its owner as metadata.
+func bindingGrantOptions(binding metav1.ObjectMeta) []grant.GrantOption {
+	if !managedBinding(binding) {
+		return nil
+	}
+
+	metadata := map[string]interface{}{"binding": binding.Name}
+	if binding.Namespace != "" {
+		metadata["binding_namespace"] = binding.Namespace
+	}
+	if owner := bindingOwner(binding); owner != "" {
+		metadata["owner"] = owner
+	}
+	immutable := &v2.GrantImmutable{}
+	if s, err := structpb.NewStruct(metadata); err == nil {
+		immutable.Metadata = s
+	}
+
+	return []grant.GrantOption{
+		grant.WithAnnotation(immutable),
+		grant.WithGrantMetadata(metadata),
+	}
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestManagedBinding(t *testing.T) {
+	controller := true
+	tests := []struct {
+		name        string
+		binding     metav1.ObjectMeta
+		wantManaged bool
+		wantOwner   string
+		// wantMetadata is the metadata of the grants of the binding
+		wantMetadata map[string]interface{}
+	}{
+		{
+			name:    "created by hand",
+			binding: metav1.ObjectMeta{Name: "alice-admin", Namespace: "team-a", Labels: map[string]string{"app": "web"}},
+		},
+		{
+			name:         "default binding",
+			binding:      metav1.ObjectMeta{Name: "system:basic-user", Annotations: map[string]string{autoupdateKey: "true"}},
+			wantManaged:  true,
+			wantMetadata: map[string]interface{}{"binding": "system:basic-user"},
+		},
+		{
+			name:    "default binding no longer updated",
+			binding: metav1.ObjectMeta{Name: "system:basic-user", Annotations: map[string]string{autoupdateKey: "false"}},
+		},
+		{
+			name:         "autoupdate label",
+			binding:      metav1.ObjectMeta{Name: "system:basic-user", Labels: map[string]string{autoupdateKey: "true"}},
+			wantManaged:  true,
+			wantMetadata: map[string]interface{}{"binding": "system:basic-user"},
+		},
+		{
+			name: "owned by a controller",
+			binding: metav1.ObjectMeta{Name: "deployer", Namespace: "team-a", OwnerReferences: []metav1.OwnerReference{
+				{Kind: "ConfigMap", Name: "settings"},
+				{Kind: "Deployment", Name: "web", Controller: &controller},
+			}},
+			wantManaged:  true,
+			wantOwner:    "Deployment/web",
+			wantMetadata: map[string]interface{}{"binding": "deployer", "binding_namespace": "team-a", "owner": "Deployment/web"},
+		},
+		{
+			name: "owned without a controller",
+			binding: metav1.ObjectMeta{Name: "deployer", OwnerReferences: []metav1.OwnerReference{
+				{Kind: "ConfigMap", Name: "settings"},
+			}},
+			wantManaged:  true,
+			wantOwner:    "ConfigMap/settings",
+			wantMetadata: map[string]interface{}{"binding": "deployer", "owner": "ConfigMap/settings"},
+		},
+		{
+			name: "managed by OLM",
+			binding: metav1.ObjectMeta{Name: "operator", Namespace: "operators", Labels: map[string]string{
+				olmOwnerLabel:          "my-operator.v1.0.0",
+				olmOwnerKindLabel:      "ClusterServiceVersion",
+				olmOwnerNamespaceLabel: "operators",
+			}},
+			wantManaged: true,
+			wantOwner:   "ClusterServiceVersion/operators/my-operator.v1.0.0",
+			wantMetadata: map[string]interface{}{
+				"binding":           "operator",
+				"binding_namespace": "operators",
+				"owner":             "ClusterServiceVersion/operators/my-operator.v1.0.0",
+			},
+		},
+		{
+			name:         "other label of OLM",
+			binding:      metav1.ObjectMeta{Name: "operator", Labels: map[string]string{"olm.managed": "true"}},
+			wantManaged:  true,
+			wantMetadata: map[string]interface{}{"binding": "operator"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.wantManaged, managedBinding(tt.binding))
+			require.Equal(t, tt.wantOwner, bindingOwner(tt.binding))
+
+			opts := bindingGrantOptions(tt.binding)
+			if !tt.wantManaged {
+				require.Empty(t, opts)
+				return
+			}
+			gnt := newSubjectGrant(
+				&v2.Resource{Id: &v2.ResourceId{ResourceType: clusterRoleResourceTypeID, Resource: "admin-uid"}},
+				"member",
+				&v2.ResourceId{ResourceType: userResourceTypeID, Resource: "alice-uid"},
+				opts...,
+			)
+			annos := annotations.Annotations(gnt.Annotations)
+			immutable := &v2.GrantImmutable{}
+			ok, err := annos.Pick(immutable)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, tt.wantMetadata, immutable.Metadata.AsMap())
+			metadata := &v2.GrantMetadata{}
+			ok, err = annos.Pick(metadata)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, tt.wantMetadata, metadata.Metadata.AsMap())
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package client
+
+// namespaces.go fetches namespaced objects from many namespaces at
+// once. The namespaces of a page are listed concurrently, how many at
+// a time is bounded by the concurrency of the client.
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"golang.org/x/sync/errgroup"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultConcurrency is the amount of namespaces listed at the same time.
+	DefaultConcurrency = 4
+	// AllNamespaces selects every namespace of the cluster.
+	AllNamespaces = "*"
+)
+
+func (c *Client) listNamespaces(ctx context.Context, opts metav1.ListOptions) ([]metav1.PartialObjectMetadata, string, error) {
+	if c.cache != nil {
+		return cachedList[metav1.PartialObjectMetadata](c.cache, namespacesResource, "")(ctx, opts)
+	}
+	list, err := c.metadataClient.Resource(namespacesResource).List(ctx, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	return list.Items, list.Continue, nil
+}
+
+// namespaceNames returns the sorted names of the namespaces to sync.
+// Namespaces named by the configuration are filtered by name only, their
+// labels are only known when every namespace is listed.
+func (c *Client) namespaceNames(ctx context.Context) ([]string, error) {
+	if !slices.Contains(c.namespaces, AllNamespaces) {
+		var names []string
+		for _, name := range c.namespaces {
+			if c.filters.Namespaces.Match(name, nil) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		return slices.Compact(names), nil
+	}
+
+	list, err := listAll(ctx, c, c.listNamespaces)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list namespaces, error: %w", err)
+	}
+	list = filterObjects(c.filters.Namespaces, list)
+	names := make([]string, 0, len(list))
+	for _, ns := range list {
+		names = append(names, ns.Name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// ListNamespaces list the synced namespaces, all at once.
+func (c *Client) ListNamespaces(ctx context.Context, pToken *pagination.Token) ([]*v2.Resource, string, error) {
+	names, err := c.namespaceNames(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	namespaces, err := convertNamespaces2Resources(names)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to convert namespaces to []*v2.Resource, error: %w", err)
+	}
+
+	return c.scope(namespaces), "", nil
+}
+
+// namespacedPage lists all the objects of a batch of namespaces, the
+// namespaces of the batch are listed concurrently. The page token is
+// the name of the last namespace of the previous batch, so namespaces
+// created or deleted between pages don't shift the next batch.
+func namespacedPage[T any](ctx context.Context, c *Client, pToken *pagination.Token, list func(namespace string) listFunc[T]) ([]T, string, error) {
+	names, err := c.namespaceNames(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	start := 0
+	if pToken != nil && pToken.Token != "" {
+		start = sort.SearchStrings(names, pToken.Token)
+		if start < len(names) && names[start] == pToken.Token {
+			start++
+		}
+	}
+	end := min(start+c.concurrency, len(names))
+	batch := names[start:end]
+
+	results := make([][]T, len(batch))
+	g, gctx := errgroup.WithContext(ctx)
+	for i, namespace := range batch {
+		g.Go(func() error {
+			items, err := listAll(gctx, c, list(namespace))
+			if err != nil {
+				return fmt.Errorf("namespace %s, error: %w", namespace, err)
+			}
+			results[i] = items
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, "", err
+	}
+
+	var items []T
+	for _, result := range results {
+		items = append(items, result...)
+	}
+	next := ""
+	if end < len(names) {
+		next = batch[len(batch)-1]
+	}
+
+	return items, next, nil
+}
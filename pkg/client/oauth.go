@@ -0,0 +1,209 @@
+package client
+
+// oauth.go logs in the OpenShift OAuth server with a username and a
+// password, the same way `oc login` does, to get the bearer tokens
+// used against the API server.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+)
+
+const (
+	// challengingClientID is the OAuth client of OpenShift that accepts
+	// basic authentication challenges.
+	challengingClientID = "openshift-challenging-client"
+	// tokenExpiryLeeway gets a new token a bit before the current one expires.
+	tokenExpiryLeeway = time.Minute
+	// oauthLoginTimeout bounds a login, tokens are requested whenever
+	// the current one expires, long after the connector was created.
+	oauthLoginTimeout = time.Minute
+)
+
+var errInvalidCredentials = errors.New("the OAuth server rejected the username or password")
+
+// oauthTokenSource requests a new token to the OAuth server every time
+// Token is called, it is meant to be wrapped by a caching token source.
+// The OAuth server is discovered through the API server (`apiClient`)
+// but is a different host, reached with `oauthClient`.
+type oauthTokenSource struct {
+	log         *zap.Logger
+	apiClient   *http.Client
+	oauthClient *http.Client
+	host        string
+	username    string
+	password    string
+}
+
+// WithOAuthLogin returns a copy of `c` authenticated with the tokens
+// issued by the OpenShift OAuth server for the given username and
+// password. A new token is requested when the current one expires or
+// gets rejected by the API server. The first login happens right away
+// so bad credentials are reported early. The certificate of the OAuth
+// server is verified with the CAs of `caFile` or `caData` if set, with
+// the system CAs otherwise.
+func WithOAuthLogin(ctx context.Context, c *rest.Config, username, password, caFile string, caData []byte) (*rest.Config, error) {
+	// the OAuth server must be reached without the credentials of `c`
+	anonymous := rest.AnonymousClientConfig(c)
+	apiClient, err := oauthHTTPClient(anonymous)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the transport for the API server, error: %w", err)
+	}
+	oauthClient, err := oauthHTTPClient(oauthServerConfig(anonymous, caFile, caData))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the transport for the OAuth server, error: %w", err)
+	}
+
+	src := &oauthTokenSource{
+		log:         ctxzap.Extract(ctx),
+		apiClient:   apiClient,
+		oauthClient: oauthClient,
+		host:        c.Host,
+		username:    username,
+		password:    password,
+	}
+	ts := transport.NewCachedTokenSource(src)
+	if _, err := ts.Token(); err != nil {
+		return nil, err
+	}
+
+	anonymous.Wrap(transport.ResettableTokenSourceWrapTransport(ts))
+
+	return anonymous, nil
+}
+
+// oauthServerConfig is the configuration of the transport to the OAuth
+// server. It is served by a route of the cluster, not by the API
+// server, so the CA of the kubeconfig and the server name the API
+// server is verified with don't apply to it, only the CAs given by the
+// user do.
+func oauthServerConfig(c *rest.Config, caFile string, caData []byte) *rest.Config {
+	c = rest.CopyConfig(c)
+	c.TLSClientConfig = rest.TLSClientConfig{Insecure: c.Insecure}
+	// client-go refuses to skip the verification with a CA set
+	if !c.Insecure {
+		c.CAFile = caFile
+		c.CAData = caData
+	}
+	return c
+}
+
+func oauthHTTPClient(c *rest.Config) (*http.Client, error) {
+	rt, err := rest.TransportFor(c)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: rt,
+		Timeout:   c.Timeout,
+		// the token is carried by the redirection itself
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}, nil
+}
+
+// Token logs in the OAuth server.
+func (s *oauthTokenSource) Token() (*oauth2.Token, error) {
+	// tokens are refreshed by the transport of the API server, which
+	// doesn't pass the context of the request along
+	ctx, cancel := context.WithTimeout(context.Background(), oauthLoginTimeout)
+	defer cancel()
+
+	authorizeURL, err := s.discoverAuthorizeEndpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "token")
+	query.Set("client_id", challengingClientID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, authorizeURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(s.username, s.password)
+	// the OAuth server refuses challenges without it
+	req.Header.Set("X-CSRF-Token", "1")
+
+	resp, err := s.oauthClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to request a token to the OAuth server, error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusFound:
+	case http.StatusUnauthorized:
+		return nil, errInvalidCredentials
+	default:
+		return nil, fmt.Errorf("unexpected response from the OAuth server: %s", resp.Status)
+	}
+
+	location, err := resp.Location()
+	if err != nil {
+		return nil, fmt.Errorf("the OAuth server didn't redirect to the token, error: %w", err)
+	}
+	// the token is on the fragment of the redirection
+	fragment, err := url.ParseQuery(location.Fragment)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the response of the OAuth server, error: %w", err)
+	}
+	if e := fragment.Get("error"); e != "" {
+		return nil, fmt.Errorf("the OAuth server refused to issue a token: %s %s", e, fragment.Get("error_description"))
+	}
+	accessToken := fragment.Get("access_token")
+	if accessToken == "" {
+		return nil, errors.New("the OAuth server didn't issue a token")
+	}
+
+	token := &oauth2.Token{AccessToken: accessToken, TokenType: "Bearer"}
+	if expiresIn, err := strconv.Atoi(fragment.Get("expires_in")); err == nil && expiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(expiresIn)*time.Second - tokenExpiryLeeway)
+	}
+	s.log.Debug("logged in the OAuth server", zap.String("username", s.username), zap.Time("expiry", token.Expiry))
+
+	return token, nil
+}
+
+// discoverAuthorizeEndpoint returns the authorization endpoint of the
+// OAuth server, published by the API server.
+func (s *oauthTokenSource) discoverAuthorizeEndpoint(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(s.host, "/")+"/.well-known/oauth-authorization-server", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.apiClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to discover the OAuth server, error: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to discover the OAuth server: %s", resp.Status)
+	}
+
+	var metadata struct {
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return "", fmt.Errorf("unable to decode the metadata of the OAuth server, error: %w", err)
+	}
+	if metadata.AuthorizationEndpoint == "" {
+		return "", errors.New("the OAuth server has no authorization endpoint")
+	}
+
+	return metadata.AuthorizationEndpoint, nil
+}
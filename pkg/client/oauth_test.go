@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+)
+
+func TestOAuthServerConfig(t *testing.T) {
+	proxy := func(*http.Request) (*url.URL, error) { return url.Parse("http://proxy:3128") }
+	tests := []struct {
+		name   string
+		tls    rest.TLSClientConfig
+		caFile string
+		caData []byte
+		want   rest.TLSClientConfig
+	}{
+		{
+			name: "CA and server name of the API server",
+			tls:  rest.TLSClientConfig{CAData: []byte("api-ca"), ServerName: "api.internal"},
+			want: rest.TLSClientConfig{},
+		},
+		{
+			name:   "CA bundle of the user",
+			tls:    rest.TLSClientConfig{CAData: []byte("user-ca"), ServerName: "api.internal"},
+			caData: []byte("user-ca"),
+			want:   rest.TLSClientConfig{CAData: []byte("user-ca")},
+		},
+		{
+			name:   "CA bundle path of the user",
+			tls:    rest.TLSClientConfig{CAFile: "/etc/ca.pem", ServerName: "api.internal"},
+			caFile: "/etc/ca.pem",
+			want:   rest.TLSClientConfig{CAFile: "/etc/ca.pem"},
+		},
+		{
+			name: "insecure",
+			tls:  rest.TLSClientConfig{Insecure: true, ServerName: "api.internal"},
+			want: rest.TLSClientConfig{Insecure: true},
+		},
+		{
+			name:   "insecure with the CA bundle of the user",
+			tls:    rest.TLSClientConfig{Insecure: true},
+			caData: []byte("user-ca"),
+			want:   rest.TLSClientConfig{Insecure: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &rest.Config{Host: "https://api.example.com:6443", TLSClientConfig: tt.tls, Proxy: proxy}
+
+			got := oauthServerConfig(c, tt.caFile, tt.caData)
+			require.Equal(t, tt.want, got.TLSClientConfig)
+			require.Equal(t, c.Host, got.Host)
+			require.NotNil(t, got.Proxy)
+			// the configuration of the API server is left as is
+			require.Equal(t, tt.tls, c.TLSClientConfig)
+		})
+	}
+}
+
+// fakeOAuth serves the discovery of the OAuth server on the API server,
+// and the authorization endpoint on a different host, like OpenShift.
+// Tokens expire right away, so every request logs in again.
+func fakeOAuth(t *testing.T) (api *httptest.Server, logins *atomic.Int32) {
+	logins = &atomic.Int32{}
+	oauth := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		if username != "alice" || password != "secret" || r.Header.Get("X-CSRF-Token") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		n := logins.Add(1)
+		w.Header().Set("Location", "https://oauth.example.com/oauth/token/implicit#access_token=token-"+strconv.Itoa(int(n))+"&expires_in=1")
+		w.WriteHeader(http.StatusFound)
+	}))
+	t.Cleanup(oauth.Close)
+
+	api = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/.well-known/oauth-authorization-server" {
+			_ = json.NewEncoder(w).Encode(map[string]string{"authorization_endpoint": oauth.URL + "/oauth/authorize"})
+			return
+		}
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	t.Cleanup(api.Close)
+
+	return api, logins
+}
+
+func TestWithOAuthLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		// trustCA verifies both servers with their CA instead of
+		// skipping the verification
+		trustCA bool
+		wantErr error
+	}{
+		{name: "valid credentials", password: "secret"},
+		{name: "valid credentials with a CA bundle", password: "secret", trustCA: true},
+		{name: "invalid credentials", password: "wrong", wantErr: errInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, logins := fakeOAuth(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			c := &rest.Config{Host: api.URL, TLSClientConfig: rest.TLSClientConfig{Insecure: true}}
+			var caData []byte
+			if tt.trustCA {
+				// both test servers share the same certificate
+				caData = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: api.Certificate().Raw})
+				c.TLSClientConfig = rest.TLSClientConfig{CAData: caData}
+			}
+
+			authenticated, err := WithOAuthLogin(ctx, c, "alice", tt.password, "", caData)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				cancel()
+				return
+			}
+			require.NoError(t, err)
+			require.EqualValues(t, 1, logins.Load())
+
+			// the token is refreshed after the context the connector
+			// was created with is done
+			cancel()
+			rt, err := rest.TransportFor(authenticated)
+			require.NoError(t, err)
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, api.URL+"/api", nil)
+			require.NoError(t, err)
+			resp, err := (&http.Client{Transport: rt}).Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, "Bearer token-2", string(body))
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package client
+
+// oauthclients.go lists the OAuth clients of OpenShift and the users
+// that authorized them. A user authorizing a client lets the client act
+// as the user, within the authorized scopes, which nobody else reviews.
+
+import (
+	"context"
+	"fmt"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	oauthv1 "github.com/openshift/api/oauth/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// authorizedSlug is the entitlement of the users that authorized a
+// client.
+const authorizedSlug = "authorized"
+
+var (
+	oauthClientsResource              = oauthv1.GroupVersion.WithResource("oauthclients")
+	oauthClientAuthorizationsResource = oauthv1.GroupVersion.WithResource("oauthclientauthorizations")
+)
+
+func (c *Client) listOAuthClients(ctx context.Context, opts metav1.ListOptions) ([]oauthv1.OAuthClient, string, error) {
+	list, err := c.oauthClient.OAuthClients().List(ctx, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	return list.Items, list.Continue, nil
+}
+
+// listOAuthClientAuthorizations lists the authorizations of an OAuth
+// client, selected by the API server.
+func (c *Client) listOAuthClientAuthorizations(clientName string) listFunc[oauthv1.OAuthClientAuthorization] {
+	return func(ctx context.Context, opts metav1.ListOptions) ([]oauthv1.OAuthClientAuthorization, string, error) {
+		opts.FieldSelector = fields.OneTermEqualSelector("clientName", clientName).String()
+		list, err := c.oauthClient.OAuthClientAuthorizations().List(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return list.Items, list.Continue, nil
+	}
+}
+
+// oauthClientName returns the name of the OAuth client of a resource.
+func oauthClientName(client *v2.Resource) (string, error) {
+	trait, err := rs.GetAppTrait(client)
+	if err != nil {
+		return "", err
+	}
+	name, ok := rs.GetProfileStringValue(trait.Profile, "name")
+	if !ok {
+		return "", fmt.Errorf("OAuth client %s has no name on its profile", client.Id.Resource)
+	}
+	if name != client.DisplayName {
+		return "", fmt.Errorf("OAuth client %s is named %s, not %s", client.Id.Resource, name, client.DisplayName)
+	}
+	return name, nil
+}
+
+// hasOAuthClients reports whether there are OAuth clients to sync, the
+// OAuth server of OpenShift only runs along the user API.
+func (c *Client) hasOAuthClients(ctx context.Context) (bool, error) {
+	userAPI, err := c.hasUserAPI(ctx)
+	if err != nil || !userAPI {
+		return false, err
+	}
+	return c.hasOAuthAPI(ctx)
+}
+
+// ListOAuthClients list a page of the OAuth clients.
+func (c *Client) ListOAuthClients(ctx context.Context, pToken *pagination.Token) ([]*v2.Resource, string, error) {
+	served, err := c.hasOAuthClients(ctx)
+	if err != nil || !served {
+		return nil, "", err
+	}
+
+	list, next, err := listPage(ctx, c, pToken, c.listOAuthClients)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to list OAuth clients, error: %w", err)
+	}
+
+	clients, err := convertV1OAuthClients2Resources(list)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to convert []v1.OAuthClient to []*v2.Resource, error: %w", err)
+	}
+
+	return c.scope(clients), next, nil
+}
+
+// ListOAuthClientAuthorizations grants the authorization of an OAuth
+// client to the synced users of a page of the authorizations, with the
+// authorized scopes.
+func (c *Client) ListOAuthClientAuthorizations(ctx context.Context, entitlement *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, error) {
+	clientName, err := oauthClientName(entitlement)
+	if err != nil {
+		return nil, "", err
+	}
+	list, next, err := listPage(ctx, c, pToken, c.listOAuthClientAuthorizations(clientName))
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to list OAuth client authorizations, error: %w", err)
+	}
+
+	var authorizations []oauthv1.OAuthClientAuthorization
+	for _, authorization := range list {
+		if authorization.ClientName == clientName {
+			authorizations = append(authorizations, authorization)
+		}
+	}
+	if len(authorizations) == 0 {
+		return nil, next, nil
+	}
+
+	users, err := c.userIndex(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	var gnts []*v2.Grant
+	for _, authorization := range authorizations {
+		user, ok := users[authorization.UserName]
+		if !ok || !c.filters.Users.Match(authorization.UserName, nil) {
+			continue
+		}
+		gnts = append(gnts, grant.NewGrant(
+			entitlement,
+			authorizedSlug,
+			user,
+			grant.WithGrantMetadata(map[string]interface{}{
+				"scopes": stringsProfile(authorization.Scopes),
+			}),
+		))
+	}
+
+	return gnts, next, nil
+}
+
+// DeleteOAuthClientAuthorization deletes the authorization of an OAuth
+// client by a user, the client then has to ask the user again. It
+// reports whether there was an authorization to delete.
+func (c *Client) DeleteOAuthClientAuthorization(ctx context.Context, client *v2.Resource, user *v2.ResourceId) (bool, error) {
+	if user.ResourceType != userResourceTypeID {
+		return false, fmt.Errorf("only users authorize OAuth clients, not %s", user.ResourceType)
+	}
+	clientName, err := oauthClientName(client)
+	if err != nil {
+		return false, err
+	}
+	uid, err := c.unscopeID(user)
+	if err != nil {
+		return false, err
+	}
+	userName, err := c.userNameOf(ctx, user)
+	if err != nil {
+		return false, err
+	}
+
+	// the authorizations are named after the user and the client
+	name := userName + ":" + clientName
+	authorization, err := withRetry(ctx, func() (*oauthv1.OAuthClientAuthorization, error) {
+		return c.oauthClient.OAuthClientAuthorizations().Get(ctx, name, metav1.GetOptions{})
+	})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to get OAuth client authorization %s, error: %w", name, err)
+	}
+	if authorization.ClientName != clientName || authorization.UserUID != uid {
+		return false, fmt.Errorf("OAuth client authorization %s is of user %s (%s) and client %s, not of user %s and client %s",
+			name, authorization.UserName, authorization.UserUID, authorization.ClientName, uid, clientName)
+	}
+
+	_, err = withRetry(ctx, func() (struct{}, error) {
+		return struct{}{}, c.oauthClient.OAuthClientAuthorizations().Delete(ctx, name, metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &authorization.UID},
+		})
+	})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to delete OAuth client authorization %s, error: %w", name, err)
+	}
+
+	return true, nil
+}
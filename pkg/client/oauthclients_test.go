@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	oauthapiv1 "github.com/openshift/api/oauth/v1"
+	oauthfake "github.com/openshift/client-go/oauth/clientset/versioned/fake"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func clientAuthorization(userName, clientName string) *oauthapiv1.OAuthClientAuthorization {
+	return &oauthapiv1.OAuthClientAuthorization{
+		ObjectMeta: metav1.ObjectMeta{Name: userName + ":" + clientName, UID: types.UID(userName + ":" + clientName + "-uid")},
+		UserName:   userName,
+		UserUID:    userName + "-uid",
+		ClientName: clientName,
+		Scopes:     []string{"user:info"},
+	}
+}
+
+func oauthClientResource(t *testing.T, name string) *v2.Resource {
+	t.Helper()
+	client, err := convertV1OAuthClient2Resource(oauthapiv1.OAuthClient{ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name + "-uid")}})
+	require.NoError(t, err)
+	return client
+}
+
+// newOAuthTestClient is a client of a cluster with the users alice and
+// bob, and the given authorizations.
+func newOAuthTestClient(authorizations ...runtime.Object) (*Client, *oauthfake.Clientset) {
+	oauth := oauthfake.NewSimpleClientset(authorizations...)
+	c := servedClient()
+	c.oauthClient = oauth.OauthV1()
+	c.metadataClient = newMetadataFake(
+		partialObject(usersResource, "User", "", "alice", nil),
+		partialObject(usersResource, "User", "", "bob", nil),
+	)
+	return c, oauth
+}
+
+func TestListOAuthClientAuthorizations(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  *v2.Resource
+		want    []string
+		wantErr string
+	}{
+		{name: "authorized", client: oauthClientResource(t, "console"), want: []string{"alice-uid", "bob-uid"}},
+		{name: "not authorized", client: oauthClientResource(t, "grafana")},
+		{
+			name: "renamed",
+			client: func() *v2.Resource {
+				client := oauthClientResource(t, "console")
+				client.DisplayName = "grafana"
+				return client
+			}(),
+			wantErr: "is named console, not grafana",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, oauth := newOAuthTestClient(
+				clientAuthorization("alice", "console"),
+				clientAuthorization("bob", "console"),
+				// users that aren't synced aren't granted anything
+				clientAuthorization("carol", "console"),
+				clientAuthorization("alice", "cli"),
+			)
+
+			gnts, _, err := c.ListOAuthClientAuthorizations(context.Background(), tt.client, &pagination.Token{})
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			var got []string
+			for _, gnt := range gnts {
+				got = append(got, gnt.Principal.Id.Resource)
+			}
+			require.ElementsMatch(t, tt.want, got)
+
+			// the API server selects the authorizations of the client
+			for _, action := range oauth.Actions() {
+				clientName, ok := action.(k8stesting.ListAction).GetListRestrictions().Fields.RequiresExactMatch("clientName")
+				require.True(t, ok)
+				require.Equal(t, tt.client.DisplayName, clientName)
+			}
+		})
+	}
+}
+
+func TestDeleteOAuthClientAuthorization(t *testing.T) {
+	user := func(uid string) *v2.ResourceId {
+		return &v2.ResourceId{ResourceType: userResourceTypeID, Resource: uid}
+	}
+	replaced := clientAuthorization("alice", "console")
+	replaced.UserUID = "previous-alice-uid"
+
+	tests := []struct {
+		name           string
+		authorizations []runtime.Object
+		user           *v2.ResourceId
+		want           bool
+		wantErr        string
+		// wantLeft are the authorizations left
+		wantLeft []string
+	}{
+		{
+			name:           "authorized",
+			authorizations: []runtime.Object{clientAuthorization("alice", "console"), clientAuthorization("bob", "console")},
+			user:           user("alice-uid"),
+			want:           true,
+			wantLeft:       []string{"bob:console"},
+		},
+		{
+			name:           "already revoked",
+			authorizations: []runtime.Object{clientAuthorization("bob", "console")},
+			user:           user("alice-uid"),
+			wantLeft:       []string{"bob:console"},
+		},
+		{
+			name:           "user of the same name",
+			authorizations: []runtime.Object{replaced},
+			user:           user("alice-uid"),
+			wantErr:        "not of user alice-uid",
+			wantLeft:       []string{"alice:console"},
+		},
+		{
+			name:           "user not synced",
+			authorizations: []runtime.Object{clientAuthorization("carol", "console")},
+			user:           user("carol-uid"),
+			wantErr:        "user carol-uid isn't synced",
+			wantLeft:       []string{"carol:console"},
+		},
+		{
+			name:     "group",
+			user:     &v2.ResourceId{ResourceType: groupResourceTypeID, Resource: "admins-uid"},
+			wantErr:  "only users authorize OAuth clients",
+			wantLeft: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, oauth := newOAuthTestClient(tt.authorizations...)
+
+			deleted, err := c.DeleteOAuthClientAuthorization(context.Background(), oauthClientResource(t, "console"), tt.user)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, deleted)
+			}
+
+			left, err := oauth.OauthV1().OAuthClientAuthorizations().List(context.Background(), metav1.ListOptions{})
+			require.NoError(t, err)
+			names := []string{}
+			for _, authorization := range left.Items {
+				names = append(names, authorization.Name)
+			}
+			require.ElementsMatch(t, tt.wantLeft, names)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package client
+
+// pagination.go maps the page tokens of Baton SDK to the chunked list
+// API of Kubernetes (`limit` and `continue`).
+
+import (
+	"context"
+
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultPageSize is the amount of objects requested per page when
+// neither the configuration nor the SDK ask for a different size.
+const DefaultPageSize = 500
+
+// listFunc performs a single List call against the API server, it
+// returns the items of the page and the continue token of the next
+// page (empty when there are no more pages).
+type listFunc[T any] func(ctx context.Context, opts metav1.ListOptions) ([]T, string, error)
+
+// isContinueExpired reports whether the API server rejected a continue
+// token because the snapshot it points to was compacted away.
+func isContinueExpired(err error) bool {
+	return apierrors.IsResourceExpired(err) || apierrors.IsGone(err)
+}
+
+// pageSize picks the size requested by the SDK, falling back to the
+// size the client was configured with.
+func (c *Client) pageSize(pToken *pagination.Token) int64 {
+	if pToken != nil && pToken.Size > 0 {
+		return int64(pToken.Size)
+	}
+	return c.defaultPageSize
+}
+
+// listPage fetches the page pointed by `pToken`. When the continue
+// token expired (410 Gone) the listing restarts from the first page,
+// resources already synced are simply emitted again.
+func listPage[T any](ctx context.Context, c *Client, pToken *pagination.Token, list listFunc[T]) ([]T, string, error) {
+	opts := metav1.ListOptions{Limit: c.pageSize(pToken)}
+	if pToken != nil {
+		opts.Continue = pToken.Token
+	}
+
+	items, next, err := retryList(ctx, list, opts)
+	if err != nil && opts.Continue != "" && isContinueExpired(err) {
+		ctxzap.Extract(ctx).Warn("continue token expired, restarting the listing from the first page")
+		opts.Continue = ""
+		items, next, err = retryList(ctx, list, opts)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return items, next, nil
+}
+
+// listAll fetches every page of a collection. If the continue token
+// expires midway, the items gathered so far are dropped and the
+// listing starts over, once, so the result is a consistent snapshot.
+// A token expiring again fails the listing rather than looping on a
+// collection that changes faster than it can be listed.
+func listAll[T any](ctx context.Context, c *Client, list listFunc[T]) ([]T, error) {
+	var all []T
+	opts := metav1.ListOptions{Limit: c.defaultPageSize}
+	restarted := false
+	for {
+		items, next, err := retryList(ctx, list, opts)
+		if err != nil {
+			if !restarted && opts.Continue != "" && isContinueExpired(err) {
+				ctxzap.Extract(ctx).Warn("continue token expired, restarting the listing from the first page")
+				all, opts.Continue, restarted = nil, "", true
+				continue
+			}
+			return nil, err
+		}
+		all = append(all, items...)
+		if next == "" {
+			return all, nil
+		}
+		opts.Continue = next
+	}
+}
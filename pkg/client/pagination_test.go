@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakePages serves `pages` one after the other, the continue token is
+// the index of the next page. The first `expire` requests made with a
+// continue token fail with 410 Gone.
+type fakePages struct {
+	pages  [][]string
+	expire int
+	err    error
+	calls  int
+}
+
+func (f *fakePages) list(_ context.Context, opts metav1.ListOptions) ([]string, string, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, "", f.err
+	}
+	idx := 0
+	if opts.Continue != "" {
+		if f.expire > 0 {
+			f.expire--
+			return nil, "", apierrors.NewResourceExpired("continue token expired")
+		}
+		idx, _ = strconv.Atoi(opts.Continue)
+	}
+	next := ""
+	if idx+1 < len(f.pages) {
+		next = strconv.Itoa(idx + 1)
+	}
+	return f.pages[idx], next, nil
+}
+
+func TestListPage(t *testing.T) {
+	pages := [][]string{{"a", "b"}, {"c"}}
+	tests := []struct {
+		name      string
+		token     string
+		expire    int
+		err       error
+		wantItems []string
+		wantNext  string
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "first page", wantItems: []string{"a", "b"}, wantNext: "1", wantCalls: 1},
+		{name: "next page", token: "1", wantItems: []string{"c"}, wantCalls: 1},
+		{name: "expired token restarts from the first page", token: "1", expire: 1, wantItems: []string{"a", "b"}, wantNext: "1", wantCalls: 2},
+		{name: "error", err: apierrors.NewForbidden(usersResource.GroupResource(), "", errors.New("denied")), wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakePages{pages: pages, expire: tt.expire, err: tt.err}
+			c := &Client{defaultPageSize: 2}
+
+			items, next, err := listPage(context.Background(), c, &pagination.Token{Token: tt.token}, f.list)
+			require.Equal(t, tt.wantCalls, f.calls)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantItems, items)
+			require.Equal(t, tt.wantNext, next)
+		})
+	}
+}
+
+func TestListAll(t *testing.T) {
+	pages := [][]string{{"a", "b"}, {"c", "d"}, {"e"}}
+	tests := []struct {
+		name      string
+		expire    int
+		wantItems []string
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "every page", wantItems: []string{"a", "b", "c", "d", "e"}, wantCalls: 3},
+		{name: "expired token restarts once", expire: 1, wantItems: []string{"a", "b", "c", "d", "e"}, wantCalls: 5},
+		{name: "expired token after the restart fails", expire: 2, wantCalls: 4, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakePages{pages: pages, expire: tt.expire}
+			c := &Client{defaultPageSize: 2}
+
+			items, err := listAll(context.Background(), c, f.list)
+			require.Equal(t, tt.wantCalls, f.calls)
+			if tt.wantErr {
+				require.True(t, isContinueExpired(err))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantItems, items)
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package client
+
+// permissions.go breaks the rules of roles and cluster roles down into
+// permissions, one per verb and resource, for reviewers that care about
+// who can "get secrets" rather than about which role grants it. The
+// wildcards of the rules are expanded against the API discovery.
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/client-go/discovery"
+)
+
+// Permission is a verb allowed by a role on a resource (optionally a
+// single object of it) or on a non-resource URL.
+type Permission struct {
+	Verb           string
+	Group          string
+	Resource       string
+	ResourceName   string
+	NonResourceURL string
+}
+
+// Name identifies the permission within its role, e.g. `get secrets`,
+// `create pods/exec`, `update deployments.apps/scale`, `get configmaps
+// named cluster-config` or `get /healthz`.
+func (p Permission) Name() string {
+	if p.NonResourceURL != "" {
+		return p.Verb + " " + p.NonResourceURL
+	}
+
+	resource, subresource, _ := strings.Cut(p.Resource, "/")
+	if p.Group != "" {
+		resource += "." + p.Group
+	}
+	if subresource != "" {
+		resource += "/" + subresource
+	}
+	name := p.Verb + " " + resource
+	if p.ResourceName != "" {
+		name += " named " + p.ResourceName
+	}
+
+	return name
+}
+
+// WithPermissionEntitlements breaks the roles and cluster roles down
+// into an entitlement per permission.
+func WithPermissionEntitlements(enabled bool) Option {
+	return func(c *Client) {
+		c.permissionEntitlements = enabled
+	}
+}
+
+// apiResources indexes the verbs of the resources (and subresources)
+// served by the API server by group, it is discovered once per client.
+func (c *Client) apiResources(ctx context.Context) (map[string]map[string][]string, error) {
+	c.apiResourcesMu.Lock()
+	defer c.apiResourcesMu.Unlock()
+	if c.discovered != nil {
+		return c.discovered, nil
+	}
+
+	_, lists, err := c.k8sClient.Discovery().ServerGroupsAndResources()
+	if err != nil {
+		// groups served by unavailable aggregated API servers are
+		// missing, their wildcards are kept as is
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return nil, fmt.Errorf("unable to discover the resources of the API server, error: %w", err)
+		}
+		ctxzap.Extract(ctx).Warn("some API groups couldn't be discovered", zap.Error(err))
+	}
+
+	index := map[string]map[string][]string{}
+	for _, list := range lists {
+		group, _, _ := strings.Cut(list.GroupVersion, "/")
+		if !strings.Contains(list.GroupVersion, "/") {
+			// the core group is served as plain `v1`
+			group = ""
+		}
+		if index[group] == nil {
+			index[group] = map[string][]string{}
+		}
+		for _, resource := range list.APIResources {
+			index[group][resource.Name] = normalizeStrings(append(index[group][resource.Name], resource.Verbs...))
+		}
+	}
+	c.discovered = index
+
+	return index, nil
+}
+
+// expandRule lists the permissions of a rule, expanding its wildcards
+// against the discovered resources. Wildcards that match nothing that
+// was discovered are kept as is. The groups matched by a `*` group only
+// get the resources of the rule they serve.
+func expandRule(rule rbacv1.PolicyRule, resources map[string]map[string][]string) []Permission {
+	var perms []Permission
+	for _, url := range rule.NonResourceURLs {
+		for _, verb := range rule.Verbs {
+			perms = append(perms, Permission{Verb: verb, NonResourceURL: url})
+		}
+	}
+
+	var groups []string
+	listed := map[string]bool{}
+	allGroups := false
+	for _, group := range rule.APIGroups {
+		if group == rbacv1.APIGroupAll {
+			allGroups = true
+			continue
+		}
+		if !listed[group] {
+			listed[group] = true
+			groups = append(groups, group)
+		}
+	}
+	if allGroups {
+		var discovered []string
+		for group := range resources {
+			if !listed[group] {
+				discovered = append(discovered, group)
+			}
+		}
+		sort.Strings(discovered)
+		groups = append(groups, discovered...)
+		if len(resources) == 0 {
+			listed[rbacv1.APIGroupAll] = true
+			groups = append(groups, rbacv1.APIGroupAll)
+		}
+	}
+
+	names := rule.ResourceNames
+	if len(names) == 0 {
+		names = []string{""}
+	}
+	for _, group := range groups {
+		for _, resource := range expandResources(rule.Resources, resources[group]) {
+			if _, served := resources[group][resource]; !listed[group] && !served {
+				continue
+			}
+			verbs := rule.Verbs
+			if discovered, ok := resources[group][resource]; ok && containsAll(verbs) {
+				verbs = discovered
+			}
+			for _, verb := range verbs {
+				for _, name := range names {
+					perms = append(perms, Permission{Verb: verb, Group: group, Resource: resource, ResourceName: name})
+				}
+			}
+		}
+	}
+
+	return perms
+}
+
+// expandResources expands `*` (every resource and subresource) and
+// `*/subresource` against the resources of a group.
+func expandResources(rules []string, discovered map[string][]string) []string {
+	var resources []string
+	for _, rule := range rules {
+		subresource, isWildcard := strings.CutPrefix(rule, rbacv1.ResourceAll)
+		if !isWildcard || len(discovered) == 0 {
+			resources = append(resources, rule)
+			continue
+		}
+		for resource := range discovered {
+			if subresource == "" || strings.HasSuffix(resource, subresource) && strings.Contains(resource, "/") {
+				resources = append(resources, resource)
+			}
+		}
+	}
+	return resources
+}
+
+func containsAll(verbs []string) bool {
+	for _, verb := range verbs {
+		if verb == rbacv1.VerbAll {
+			return true
+		}
+	}
+	return false
+}
+
+// RolePermissions returns the permissions allowed by a role or a
+// cluster role, from the rules on its profile, sorted by name. It is
+// empty unless the permission entitlements are enabled.
+func (c *Client) RolePermissions(ctx context.Context, role *v2.Resource) ([]Permission, error) {
+	if !c.permissionEntitlements {
+		return nil, nil
+	}
+
+	trait, err := rs.GetRoleTrait(role)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := c.apiResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var perms []Permission
+	for _, rule := range rulesFromProfile(trait.Profile) {
+		for _, perm := range expandRule(rule, resources) {
+			if seen[perm.Name()] {
+				continue
+			}
+			seen[perm.Name()] = true
+			perms = append(perms, perm)
+		}
+	}
+	sort.Slice(perms, func(i, j int) bool { return perms[i].Name() < perms[j].Name() })
+
+	return perms, nil
+}
+
+// PermissionGrants grants the permissions of a role to the role itself,
+// expanded to every subject that holds its membership.
+func (c *Client) PermissionGrants(ctx context.Context, role *v2.Resource) ([]*v2.Grant, error) {
+	perms, err := c.RolePermissions(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+
+	gnts := make([]*v2.Grant, 0, len(perms))
+	for _, perm := range perms {
+		gnts = append(gnts, grant.NewGrant(
+			role,
+			perm.Name(),
+			role.Id,
+			grant.WithAnnotation(&v2.GrantExpandable{
+				EntitlementIds: []string{ent.NewEntitlementID(role, "member")},
+			}),
+		))
+	}
+
+	return gnts, nil
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestExpandRule(t *testing.T) {
+	discovered := map[string]map[string][]string{
+		"": {
+			"pods":       {"create", "delete", "get", "list", "watch"},
+			"pods/exec":  {"create", "get"},
+			"configmaps": {"get", "list"},
+		},
+		"apps": {
+			"deployments":       {"get", "list", "update"},
+			"deployments/scale": {"get", "update"},
+		},
+		"batch": {
+			"jobs": {"get", "list"},
+		},
+	}
+	tests := []struct {
+		name      string
+		rule      rbacv1.PolicyRule
+		resources map[string]map[string][]string
+		want      []string
+	}{
+		{
+			name: "resources",
+			rule: rbacv1.PolicyRule{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"pods", "pods/exec"}},
+			want: []string{"get pods", "get pods/exec", "list pods", "list pods/exec"},
+		},
+		{
+			name: "non-resource URLs",
+			rule: rbacv1.PolicyRule{Verbs: []string{"get"}, NonResourceURLs: []string{"/healthz", "/version"}},
+			want: []string{"get /healthz", "get /version"},
+		},
+		{
+			name: "resource names",
+			rule: rbacv1.PolicyRule{Verbs: []string{"get", "update"}, APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{"cluster-config"}},
+			want: []string{"get configmaps named cluster-config", "update configmaps named cluster-config"},
+		},
+		{
+			// only the groups that serve them get the resources
+			name: "* groups",
+			rule: rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{"*"}, Resources: []string{"deployments", "pods"}},
+			want: []string{"get deployments.apps", "get pods"},
+		},
+		{
+			name: "* groups and a listed group",
+			rule: rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{"*", "example.com"}, Resources: []string{"jobs", "widgets"}},
+			want: []string{"get jobs.batch", "get jobs.example.com", "get widgets.example.com"},
+		},
+		{
+			name: "* resources",
+			rule: rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{"apps"}, Resources: []string{"*"}},
+			want: []string{"get deployments.apps", "get deployments.apps/scale"},
+		},
+		{
+			name: "* groups and * resources",
+			rule: rbacv1.PolicyRule{Verbs: []string{"list"}, APIGroups: []string{"*"}, Resources: []string{"*"}},
+			want: []string{
+				"list configmaps", "list deployments.apps", "list deployments.apps/scale", "list jobs.batch",
+				"list pods", "list pods/exec",
+			},
+		},
+		{
+			name: "*/scale",
+			rule: rbacv1.PolicyRule{Verbs: []string{"update"}, APIGroups: []string{"*"}, Resources: []string{"*/scale"}},
+			want: []string{"update deployments.apps/scale"},
+		},
+		{
+			name: "* verbs",
+			rule: rbacv1.PolicyRule{Verbs: []string{"*"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+			want: []string{"get deployments.apps", "list deployments.apps", "update deployments.apps"},
+		},
+		{
+			name: "* verbs of a resource that wasn't discovered",
+			rule: rbacv1.PolicyRule{Verbs: []string{"*"}, APIGroups: []string{"apps"}, Resources: []string{"statefulsets"}},
+			want: []string{"* statefulsets.apps"},
+		},
+		{
+			name: "group that wasn't discovered",
+			rule: rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{"example.com"}, Resources: []string{"*", "widgets"}},
+			want: []string{"get *.example.com", "get widgets.example.com"},
+		},
+		{
+			name:      "nothing discovered",
+			rule:      rbacv1.PolicyRule{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}},
+			resources: map[string]map[string][]string{},
+			want:      []string{"* *.*"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resources := discovered
+			if tt.resources != nil {
+				resources = tt.resources
+			}
+
+			var got []string
+			for _, perm := range expandRule(tt.rule, resources) {
+				got = append(got, perm.Name())
+			}
+			sort.Strings(got)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package client
+
+// retry.go retries the requests that failed for reasons that go away
+// on their own: throttling, server timeouts, etcd leader elections
+// and transient network errors.
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/retry"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+)
+
+// defaultRetryConfig is used for every request of the client.
+var defaultRetryConfig = retry.RetryConfig{
+	MaxAttempts:  5,
+	InitialDelay: time.Second,
+	MaxDelay:     30 * time.Second,
+}
+
+// retryable reports whether a request that failed with `err` is worth
+// trying again.
+func retryable(err error) bool {
+	switch {
+	case apierrors.IsTooManyRequests(err),
+		apierrors.IsServerTimeout(err),
+		apierrors.IsTimeout(err),
+		apierrors.IsServiceUnavailable(err):
+		return true
+	case apierrors.IsInternalError(err):
+		// etcd leader elections surface as internal errors
+		return strings.Contains(err.Error(), "etcdserver: leader changed") ||
+			strings.Contains(err.Error(), "etcdserver: request timed out")
+	}
+
+	return utilnet.IsConnectionReset(err) ||
+		utilnet.IsConnectionRefused(err) ||
+		utilnet.IsProbableEOF(err) ||
+		utilnet.IsHTTP2ConnectionLost(err) ||
+		utilnet.IsTimeout(err)
+}
+
+// asRetryStatus wraps `err` into the status the retryer of the SDK
+// waits on, carrying the delay suggested by the API server if any.
+func asRetryStatus(err error) error {
+	st := status.New(codes.Unavailable, err.Error())
+	if seconds, ok := apierrors.SuggestsClientDelay(err); ok && seconds > 0 {
+		resetAt := time.Now().Add(time.Duration(seconds) * time.Second)
+		if withDetails, derr := st.WithDetails(&v2.RateLimitDescription{ResetAt: timestamppb.New(resetAt)}); derr == nil {
+			st = withDetails
+		}
+	}
+	return st.Err()
+}
+
+// withRetry calls `fn` until it succeeds, fails with an error that is
+// not retryable or the attempts run out.
+func withRetry[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	retryer := retry.NewRetryer(ctx, defaultRetryConfig)
+	for {
+		result, err := fn()
+		if err == nil || !retryable(err) {
+			return result, err
+		}
+		if !retryer.ShouldWaitAndRetry(ctx, asRetryStatus(err)) {
+			return result, err
+		}
+	}
+}
+
+// retryList calls a listFunc through withRetry.
+func retryList[T any](ctx context.Context, list listFunc[T], opts metav1.ListOptions) ([]T, string, error) {
+	type page struct {
+		items []T
+		next  string
+	}
+	p, err := withRetry(ctx, func() (page, error) {
+		items, next, err := list(ctx, opts)
+		return page{items: items, next: next}, err
+	})
+	return p.items, p.next, err
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestRetryable(t *testing.T) {
+	gr := rolesResource.GroupResource()
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "too many requests", err: apierrors.NewTooManyRequests("slow down", 1), want: true},
+		{name: "server timeout", err: apierrors.NewServerTimeout(gr, "list", 1), want: true},
+		{name: "timeout", err: apierrors.NewTimeoutError("timed out", 1), want: true},
+		{name: "service unavailable", err: apierrors.NewServiceUnavailable("unavailable"), want: true},
+		{name: "etcd leader changed", err: apierrors.NewInternalError(errors.New("etcdserver: leader changed")), want: true},
+		{name: "etcd request timed out", err: apierrors.NewInternalError(errors.New("etcdserver: request timed out")), want: true},
+		{name: "other internal error", err: apierrors.NewInternalError(errors.New("boom"))},
+		{name: "connection reset", err: syscall.ECONNRESET, want: true},
+		{name: "connection refused", err: syscall.ECONNREFUSED, want: true},
+		{name: "unexpected EOF", err: io.ErrUnexpectedEOF, want: true},
+		{name: "forbidden", err: apierrors.NewForbidden(gr, "admin", errors.New("denied"))},
+		{name: "not found", err: apierrors.NewNotFound(gr, "admin")},
+		{name: "expired continue token", err: apierrors.NewResourceExpired("expired")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, retryable(tt.err))
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	config := defaultRetryConfig
+	defaultRetryConfig.InitialDelay, defaultRetryConfig.MaxDelay = time.Millisecond, time.Millisecond
+	defer func() { defaultRetryConfig = config }()
+
+	unavailable := apierrors.NewServiceUnavailable("unavailable")
+	forbidden := apierrors.NewForbidden(rolesResource.GroupResource(), "", errors.New("denied"))
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "success", errs: []error{nil}, wantCalls: 1},
+		{name: "transient failures", errs: []error{unavailable, unavailable, nil}, wantCalls: 3},
+		{name: "permanent failure", errs: []error{forbidden}, wantCalls: 1, wantErr: forbidden},
+		// the first call, then up to MaxAttempts retries
+		{name: "attempts run out", errs: []error{unavailable, unavailable, unavailable, unavailable, unavailable, unavailable, nil}, wantCalls: 6, wantErr: unavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			got, err := withRetry(context.Background(), func() (int, error) {
+				err := tt.errs[calls]
+				calls++
+				return calls, err
+			})
+			require.Equal(t, tt.wantCalls, calls)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantCalls, got)
+		})
+	}
+}
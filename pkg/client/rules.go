@@ -0,0 +1,142 @@
+package client
+
+// rules.go normalizes the policy rules of roles, so the profile of a
+// role tells what it allows in a stable and compact form.
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// lastAppliedAnnotation is a copy of the whole object kept by `kubectl
+// apply`, it says nothing the rest of the profile doesn't.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// normalizeRules sorts and deduplicates the fields of the rules, then
+// merges the rules that only differ by their verbs. The result is
+// sorted, so equal sets of rules always look the same.
+func normalizeRules(rules []rbacv1.PolicyRule) []rbacv1.PolicyRule {
+	merged := map[string]*rbacv1.PolicyRule{}
+	for _, rule := range rules {
+		rule = rbacv1.PolicyRule{
+			Verbs:           normalizeStrings(rule.Verbs),
+			APIGroups:       normalizeStrings(rule.APIGroups),
+			Resources:       normalizeStrings(rule.Resources),
+			ResourceNames:   normalizeStrings(rule.ResourceNames),
+			NonResourceURLs: normalizeStrings(rule.NonResourceURLs),
+		}
+		key := ruleKey(rule)
+		if existing, ok := merged[key]; ok {
+			existing.Verbs = normalizeStrings(append(existing.Verbs, rule.Verbs...))
+			continue
+		}
+		merged[key] = &rule
+	}
+
+	keys := make([]string, 0, len(merged))
+	for key := range merged {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	normalized := make([]rbacv1.PolicyRule, 0, len(keys))
+	for _, key := range keys {
+		normalized = append(normalized, *merged[key])
+	}
+
+	return normalized
+}
+
+// ruleKey identifies what a rule applies to, regardless of its verbs.
+func ruleKey(rule rbacv1.PolicyRule) string {
+	return strings.Join([]string{
+		strings.Join(rule.APIGroups, ","),
+		strings.Join(rule.Resources, ","),
+		strings.Join(rule.ResourceNames, ","),
+		strings.Join(rule.NonResourceURLs, ","),
+	}, "|")
+}
+
+func normalizeStrings(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	normalized := make([]string, 0, len(values))
+	for _, value := range values {
+		normalized = append(normalized, strings.TrimSpace(value))
+	}
+	sort.Strings(normalized)
+	return slices.Compact(normalized)
+}
+
+// rulesProfile converts rules to values of a profile, empty fields are
+// left out.
+func rulesProfile(rules []rbacv1.PolicyRule) []interface{} {
+	profile := make([]interface{}, 0, len(rules))
+	for _, rule := range normalizeRules(rules) {
+		entry := map[string]interface{}{}
+		for name, values := range map[string][]string{
+			"verbs":             rule.Verbs,
+			"api_groups":        rule.APIGroups,
+			"resources":         rule.Resources,
+			"resource_names":    rule.ResourceNames,
+			"non_resource_urls": rule.NonResourceURLs,
+		} {
+			if values != nil {
+				entry[name] = stringsProfile(values)
+			}
+		}
+		profile = append(profile, entry)
+	}
+	return profile
+}
+
+// stringsProfile converts strings to a value of a profile, profiles
+// only hold lists of interface{}.
+func stringsProfile(values []string) []interface{} {
+	profile := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		profile = append(profile, value)
+	}
+	return profile
+}
+
+// stringMapProfile converts labels or annotations to a value of a profile.
+func stringMapProfile(values map[string]string) map[string]interface{} {
+	profile := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if key == lastAppliedAnnotation {
+			continue
+		}
+		profile[key] = value
+	}
+	return profile
+}
+
+// rulesFromProfile reads back the rules of a role from its profile.
+func rulesFromProfile(profile *structpb.Struct) []rbacv1.PolicyRule {
+	list := profile.GetFields()["rules"].GetListValue()
+	rules := make([]rbacv1.PolicyRule, 0, len(list.GetValues()))
+	for _, value := range list.GetValues() {
+		fields := value.GetStructValue().GetFields()
+		rules = append(rules, rbacv1.PolicyRule{
+			Verbs:           profileStrings(fields["verbs"]),
+			APIGroups:       profileStrings(fields["api_groups"]),
+			Resources:       profileStrings(fields["resources"]),
+			ResourceNames:   profileStrings(fields["resource_names"]),
+			NonResourceURLs: profileStrings(fields["non_resource_urls"]),
+		})
+	}
+	return rules
+}
+
+func profileStrings(value *structpb.Value) []string {
+	var values []string
+	for _, v := range value.GetListValue().GetValues() {
+		values = append(values, v.GetStringValue())
+	}
+	return values
+}
@@ -0,0 +1,295 @@
+package client
+
+// scc.go lists the SecurityContextConstraints of OpenShift and who may
+// use them. An SCC like `privileged` or `anyuid` is used by the pods of
+// whoever is allowed to, either listed on the SCC itself or granted the
+// `use` verb on it by RBAC.
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	securityv1 "github.com/openshift/api/security/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// sccUseVerb is the verb RBAC grants on an SCC to use it.
+	sccUseVerb = "use"
+	// serviceAccountUserPrefix prefixes the usernames of the service
+	// accounts, `system:serviceaccount:<namespace>:<name>`.
+	serviceAccountUserPrefix = "system:serviceaccount:"
+)
+
+var sccResource = securityv1.GroupVersion.WithResource("securitycontextconstraints")
+
+// hasSCCAPI reports whether the API server serves the security API of
+// OpenShift.
+func (c *Client) hasSCCAPI(ctx context.Context) (bool, error) {
+	return c.serves(ctx, securityv1.GroupVersion, "security context constraints aren't synced")
+}
+
+// ListSCCs list the security context constraints, all at once. There
+// are none on clusters that don't serve the security API.
+func (c *Client) ListSCCs(ctx context.Context, pToken *pagination.Token) ([]*v2.Resource, string, error) {
+	served, err := c.hasSCCAPI(ctx)
+	if err != nil || !served {
+		return nil, "", err
+	}
+
+	list, err := withRetry(ctx, func() (*securityv1.SecurityContextConstraintsList, error) {
+		return c.securityClient.SecurityContextConstraints().List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to list security context constraints, error: %w", err)
+	}
+
+	sccs, err := convertV1SCCs2Resources(list.Items)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to convert []v1.SecurityContextConstraints to []*v2.Resource, error: %w", err)
+	}
+
+	return c.scope(sccs), "", nil
+}
+
+// sccSubject is a subject allowed to use an SCC, and why. binding is
+// the binding that allows it, unset for the subjects listed on the SCC.
+type sccSubject struct {
+	subject rbacv1.Subject
+	source  string
+	binding metav1.ObjectMeta
+}
+
+// ListSCCGrants grants the use of an SCC to the subjects listed on it,
+// and to those bound to a role or cluster role that allows to use it.
+func (c *Client) ListSCCGrants(ctx context.Context, entitlement *v2.Resource) ([]*v2.Grant, error) {
+	name := entitlement.DisplayName
+	scc, err := withRetry(ctx, func() (*securityv1.SecurityContextConstraints, error) {
+		return c.securityClient.SecurityContextConstraints().Get(ctx, name, metav1.GetOptions{})
+	})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get security context constraints %s, error: %w", name, err)
+	}
+	// the SCC was deleted and created again since it was listed
+	if entitlement.Id.Resource != c.scopeID(&v2.ResourceId{Resource: string(scc.UID)}).Resource {
+		return nil, nil
+	}
+
+	var allowed []sccSubject
+	for _, user := range scc.Users {
+		allowed = append(allowed, sccSubject{subject: userSubject(user), source: "SecurityContextConstraints users"})
+	}
+	for _, group := range scc.Groups {
+		allowed = append(allowed, sccSubject{subject: rbacv1.Subject{Kind: rbacv1.GroupKind, Name: group}, source: "SecurityContextConstraints groups"})
+	}
+	bound, err := c.sccBoundSubjects(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	allowed = append(allowed, bound...)
+
+	subjects := make([]rbacv1.Subject, 0, len(allowed))
+	for _, s := range allowed {
+		subjects = append(subjects, s.subject)
+	}
+	index, err := c.subjectIndexFor(ctx, subjects)
+	if err != nil {
+		return nil, err
+	}
+
+	// a subject allowed many ways is granted once, with every source. It
+	// is immutable when any binding allowing it is managed, revoking the
+	// others wouldn't take the use of the SCC away.
+	var principals []*v2.ResourceId
+	sources := map[string][]interface{}{}
+	managed := map[string]metav1.ObjectMeta{}
+	for _, s := range allowed {
+		principal, ok := index.resolve(s.subject)
+		if !ok {
+			continue
+		}
+		key := principal.ResourceType + ":" + principal.Resource
+		if _, seen := sources[key]; !seen {
+			principals = append(principals, principal)
+		}
+		sources[key] = append(sources[key], s.source)
+		if _, ok := managed[key]; !ok && managedBinding(s.binding) {
+			managed[key] = s.binding
+		}
+	}
+
+	gnts := make([]*v2.Grant, 0, len(principals))
+	for _, principal := range principals {
+		key := principal.ResourceType + ":" + principal.Resource
+		opts := bindingGrantOptions(managed[key])
+		// the sources replace the metadata of the binding, which the
+		// immutable annotation keeps
+		opts = append(opts, grant.WithGrantMetadata(map[string]interface{}{
+			"sources": sources[key],
+		}))
+		gnts = append(gnts, newSubjectGrant(entitlement, sccUseVerb, principal, opts...))
+	}
+
+	return gnts, nil
+}
+
+// sccPolicy holds the roles and cluster roles that allow to use some
+// SCC, with their bindings. It is reused until stale and shared by the
+// SCCs, the other roles and bindings aren't kept.
+type sccPolicy struct {
+	// clusterRoles and roles (by `<namespace>/<name>`) map the roles to
+	// their rules allowing to use SCCs.
+	clusterRoles    map[string][]rbacv1.PolicyRule
+	roles           map[string][]rbacv1.PolicyRule
+	clusterBindings []rbacv1.ClusterRoleBinding
+	bindings        []rbacv1.RoleBinding
+}
+
+// sccPolicyIndex returns the roles and cluster roles that allow to use
+// some SCC, and their bindings in the synced namespaces.
+func (c *Client) sccPolicyIndex(ctx context.Context) (*sccPolicy, error) {
+	return c.sccPolicy.get(c.staleAfter(), func() (*sccPolicy, error) {
+		policy := &sccPolicy{clusterRoles: map[string][]rbacv1.PolicyRule{}, roles: map[string][]rbacv1.PolicyRule{}}
+		clusterRoles, err := listAll(ctx, c, c.listClusterRoles)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list cluster roles, error: %w", err)
+		}
+		for _, role := range clusterRoles {
+			if rules := sccUseRules(role.Rules); len(rules) > 0 {
+				policy.clusterRoles[role.Name] = rules
+			}
+		}
+
+		clusterBindings, err := listAll(ctx, c, c.listClusterRoleBindings)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list cluster role bindings, error: %w", err)
+		}
+		for _, binding := range clusterBindings {
+			if binding.RoleRef.Kind == "ClusterRole" && policy.clusterRoles[binding.RoleRef.Name] != nil {
+				policy.clusterBindings = append(policy.clusterBindings, binding)
+			}
+		}
+
+		namespaces, err := c.bindingNamespaces(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, namespace := range namespaces {
+			roles, err := listAll(ctx, c, c.listRoles(namespace))
+			if err != nil {
+				return nil, fmt.Errorf("unable to list roles, namespace %s, error: %w", namespace, err)
+			}
+			for _, role := range roles {
+				if rules := sccUseRules(role.Rules); len(rules) > 0 {
+					policy.roles[role.Namespace+"/"+role.Name] = rules
+				}
+			}
+
+			bindings, err := listAll(ctx, c, c.listRoleBindings(namespace))
+			if err != nil {
+				return nil, fmt.Errorf("unable to list role bindings, namespace %s, error: %w", namespace, err)
+			}
+			for _, binding := range bindings {
+				switch {
+				case binding.RoleRef.Kind == "ClusterRole" && policy.clusterRoles[binding.RoleRef.Name] != nil:
+				case binding.RoleRef.Kind == "Role" && policy.roles[binding.Namespace+"/"+binding.RoleRef.Name] != nil:
+				default:
+					continue
+				}
+				policy.bindings = append(policy.bindings, withSubjectNamespaces(binding))
+			}
+		}
+
+		return policy, nil
+	})
+}
+
+// sccBoundSubjects returns the subjects of the cluster role bindings
+// and role bindings of the roles that allow to use an SCC. Role
+// bindings only allow the pods of their namespace to use it.
+func (c *Client) sccBoundSubjects(ctx context.Context, name string) ([]sccSubject, error) {
+	policy, err := c.sccPolicyIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var allowed []sccSubject
+	for _, binding := range policy.clusterBindings {
+		if !allowsSCC(policy.clusterRoles[binding.RoleRef.Name], name) {
+			continue
+		}
+		for _, subject := range binding.Subjects {
+			allowed = append(allowed, sccSubject{
+				subject: subject,
+				source:  fmt.Sprintf("ClusterRoleBinding %s (ClusterRole %s)", binding.Name, binding.RoleRef.Name),
+				binding: binding.ObjectMeta,
+			})
+		}
+	}
+	for _, binding := range policy.bindings {
+		rules := policy.clusterRoles[binding.RoleRef.Name]
+		if binding.RoleRef.Kind == "Role" {
+			rules = policy.roles[binding.Namespace+"/"+binding.RoleRef.Name]
+		}
+		if !allowsSCC(rules, name) {
+			continue
+		}
+		for _, subject := range binding.Subjects {
+			allowed = append(allowed, sccSubject{
+				subject: subject,
+				source:  fmt.Sprintf("RoleBinding %s/%s (%s %s)", binding.Namespace, binding.Name, binding.RoleRef.Kind, binding.RoleRef.Name),
+				binding: binding.ObjectMeta,
+			})
+		}
+	}
+
+	return allowed, nil
+}
+
+// sccUseRules returns the rules that allow to use some SCC.
+func sccUseRules(rules []rbacv1.PolicyRule) []rbacv1.PolicyRule {
+	var use []rbacv1.PolicyRule
+	for _, rule := range rules {
+		if matchesRule(rule.Verbs, sccUseVerb, rbacv1.VerbAll) &&
+			matchesRule(rule.APIGroups, sccResource.Group, rbacv1.APIGroupAll) &&
+			matchesRule(rule.Resources, sccResource.Resource, rbacv1.ResourceAll) {
+			use = append(use, rule)
+		}
+	}
+	return use
+}
+
+// allowsSCC reports whether rules allow to use the named SCC.
+func allowsSCC(rules []rbacv1.PolicyRule, name string) bool {
+	for _, rule := range sccUseRules(rules) {
+		if len(rule.ResourceNames) == 0 || slices.Contains(rule.ResourceNames, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesRule(values []string, value, wildcard string) bool {
+	return slices.Contains(values, value) || slices.Contains(values, wildcard)
+}
+
+// userSubject converts a username to a subject, the usernames of the
+// service accounts are converted to service account subjects.
+func userSubject(name string) rbacv1.Subject {
+	if rest, ok := strings.CutPrefix(name, serviceAccountUserPrefix); ok {
+		if namespace, sa, ok := strings.Cut(rest, ":"); ok {
+			return rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: sa, Namespace: namespace}
+		}
+	}
+	return rbacv1.Subject{Kind: rbacv1.UserKind, Name: name}
+}
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	securityv1 "github.com/openshift/api/security/v1"
+	userapiv1 "github.com/openshift/api/user/v1"
+	securityfake "github.com/openshift/client-go/security/clientset/versioned/fake"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAllowsSCC(t *testing.T) {
+	use := func(groups, resources, names []string, verbs ...string) rbacv1.PolicyRule {
+		return rbacv1.PolicyRule{Verbs: verbs, APIGroups: groups, Resources: resources, ResourceNames: names}
+	}
+	security := []string{"security.openshift.io"}
+	sccs := []string{"securitycontextconstraints"}
+
+	tests := []struct {
+		name  string
+		rules []rbacv1.PolicyRule
+		want  bool
+	}{
+		{name: "named", rules: []rbacv1.PolicyRule{use(security, sccs, []string{"anyuid"}, "use")}, want: true},
+		{name: "every SCC", rules: []rbacv1.PolicyRule{use(security, sccs, nil, "use")}, want: true},
+		{name: "another SCC", rules: []rbacv1.PolicyRule{use(security, sccs, []string{"privileged"}, "use")}},
+		{name: "wildcards", rules: []rbacv1.PolicyRule{use([]string{"*"}, []string{"*"}, nil, "*")}, want: true},
+		{name: "other verbs", rules: []rbacv1.PolicyRule{use(security, sccs, nil, "get", "list")}},
+		{name: "other API group", rules: []rbacv1.PolicyRule{use([]string{""}, sccs, nil, "use")}},
+		{name: "other resources", rules: []rbacv1.PolicyRule{use(security, []string{"rangeallocations"}, nil, "use")}},
+		{
+			name:  "one of many rules",
+			rules: []rbacv1.PolicyRule{use(security, sccs, nil, "get"), use(security, sccs, []string{"restricted", "anyuid"}, "use")},
+			want:  true,
+		},
+		{name: "no rules"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, allowsSCC(tt.rules, "anyuid"))
+		})
+	}
+}
+
+func TestUserSubject(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		want     rbacv1.Subject
+	}{
+		{name: "user", username: "alice", want: rbacv1.Subject{Kind: rbacv1.UserKind, Name: "alice"}},
+		{
+			name:     "service account",
+			username: "system:serviceaccount:team-a:deployer",
+			want:     rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: "team-a", Name: "deployer"},
+		},
+		{
+			name:     "malformed service account",
+			username: "system:serviceaccount:deployer",
+			want:     rbacv1.Subject{Kind: rbacv1.UserKind, Name: "system:serviceaccount:deployer"},
+		},
+		{name: "system user", username: "system:admin", want: rbacv1.Subject{Kind: rbacv1.UserKind, Name: "system:admin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, userSubject(tt.username))
+		})
+	}
+}
+
+func TestListSCCGrants(t *testing.T) {
+	useAnyUID := []rbacv1.PolicyRule{{
+		Verbs:         []string{"use"},
+		APIGroups:     []string{"security.openshift.io"},
+		Resources:     []string{"securitycontextconstraints"},
+		ResourceNames: []string{"anyuid"},
+	}}
+	managed := metav1.ObjectMeta{Name: "operator", Labels: map[string]string{"olm.owner": "my-operator"}}
+	k8s := k8sfake.NewSimpleClientset(
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "use-anyuid"}, Rules: useAnyUID},
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "view"}},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: managed,
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "use-anyuid"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "alice"}},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "viewers"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "carol"}},
+		},
+		&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "use-anyuid"}, Rules: useAnyUID},
+		roleBinding("team-a", "deployer", "Role", "use-anyuid", rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "deployer"}),
+	)
+	// the fake clientset can't guess the resource of the kind of SCCs
+	security := securityfake.NewSimpleClientset()
+	for _, scc := range []*securityv1.SecurityContextConstraints{
+		{ObjectMeta: metav1.ObjectMeta{Name: "anyuid", UID: "anyuid-uid"}, Users: []string{"alice", "bob"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "privileged", UID: "privileged-uid"}, Users: []string{"alice"}},
+	} {
+		require.NoError(t, security.Tracker().Create(sccResource, scc, ""))
+	}
+	meta := newMetadataFake(
+		partialObject(usersResource, "User", "", "alice", nil),
+		partialObject(usersResource, "User", "", "bob", nil),
+		partialObject(usersResource, "User", "", "carol", nil),
+		partialObject(serviceAccountsResource, "ServiceAccount", "team-a", "deployer", nil),
+		partialObject(namespacesResource, "Namespace", "", "team-a", nil),
+	)
+	c := servedClient(userapiv1.GroupVersion)
+	c.k8sClient, c.securityClient, c.metadataClient, c.namespaces = k8s, security.SecurityV1(), meta, []string{AllNamespaces}
+
+	type want struct {
+		sources   int
+		immutable bool
+	}
+	tests := []struct {
+		name string
+		scc  string
+		id   string
+		want map[string]want
+	}{
+		{
+			name: "anyuid",
+			scc:  "anyuid",
+			id:   "anyuid-uid",
+			want: map[string]want{
+				"alice-uid": {sources: 2, immutable: true},
+				"bob-uid":   {sources: 1},
+				// the service account of the namespace of the role binding
+				"deployer-uid": {sources: 1},
+			},
+		},
+		{name: "privileged", scc: "privileged", id: "privileged-uid", want: map[string]want{"alice-uid": {sources: 1}}},
+		{name: "deleted", scc: "restricted", id: "restricted-uid", want: map[string]want{}},
+		{name: "created again", scc: "privileged", id: "previous-privileged-uid", want: map[string]want{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gnts, err := c.ListSCCGrants(context.Background(), &v2.Resource{
+				Id:          &v2.ResourceId{ResourceType: sccResourceTypeID, Resource: tt.id},
+				DisplayName: tt.scc,
+			})
+			require.NoError(t, err)
+
+			got := map[string]want{}
+			for _, gnt := range gnts {
+				annos := annotations.Annotations(gnt.Annotations)
+				metadata := &v2.GrantMetadata{}
+				ok, err := annos.Pick(metadata)
+				require.NoError(t, err)
+				require.True(t, ok)
+				got[gnt.Principal.Id.Resource] = want{
+					sources:   len(metadata.Metadata.Fields["sources"].GetListValue().GetValues()),
+					immutable: annos.Contains(&v2.GrantImmutable{}),
+				}
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	// the roles and bindings are listed once for every SCC
+	var lists int
+	for _, action := range k8s.Actions() {
+		if action.GetResource().Resource == "clusterroles" {
+			lists++
+		}
+	}
+	require.Equal(t, 1, lists)
+}
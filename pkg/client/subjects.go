@@ -0,0 +1,144 @@
+package client
+
+// subjects.go resolves the subjects of role bindings to the resources
+// synced by the connector.
+
+import (
+	"context"
+	"fmt"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// subjectIndex maps the subjects of role bindings to the resource ID
+// of their principal.
+type subjectIndex struct {
+	users  map[string]*v2.ResourceId
+	groups map[string]*v2.ResourceId
+	// serviceAccounts are keyed by namespace, then by name
+	serviceAccounts map[string]map[string]*v2.ResourceId
+}
+
+// subjectIndexFor builds the index of the given subjects, only the
+// kinds of principals (and the namespaces of service accounts) that
+// are referenced get listed. The listings are indexed, and reused until
+// stale. The service accounts of the namespaces that aren't synced
+// (e.g. `openshift-*` ones bound by cluster role bindings) aren't
+// resolved, they aren't synced either and may not be listed.
+func (c *Client) subjectIndexFor(ctx context.Context, subjects []rbacv1.Subject) (*subjectIndex, error) {
+	userAPI, err := c.hasUserAPI(ctx)
+	if err != nil {
+		return nil, err
+	}
+	index := &subjectIndex{}
+	if !userAPI {
+		index = c.derivedSubjectIndex(subjects)
+	}
+	for _, subject := range subjects {
+		switch subject.Kind {
+		case rbacv1.UserKind:
+			if index.users == nil {
+				index.users, err = c.userIndex(ctx)
+			}
+		case rbacv1.GroupKind:
+			if index.groups == nil {
+				index.groups, err = c.groupIndex(ctx)
+			}
+		case rbacv1.ServiceAccountKind:
+			if _, ok := index.serviceAccounts[subject.Namespace]; ok {
+				continue
+			}
+			if index.serviceAccounts == nil {
+				index.serviceAccounts = map[string]map[string]*v2.ResourceId{}
+			}
+			var synced map[string]bool
+			synced, err = c.syncedNamespaces(ctx)
+			if err == nil && synced[subject.Namespace] {
+				index.serviceAccounts[subject.Namespace], err = c.serviceAccountIndex(ctx, subject.Namespace)
+			} else {
+				index.serviceAccounts[subject.Namespace] = nil
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the subjects of role bindings, error: %w", err)
+		}
+	}
+
+	return index, nil
+}
+
+// groupIndex maps the name of every group of the cluster to its
+// resource ID, it is reused until stale.
+func (c *Client) groupIndex(ctx context.Context) (map[string]*v2.ResourceId, error) {
+	return c.groups.get(c.staleAfter(), func() (map[string]*v2.ResourceId, error) {
+		list, err := listAll(ctx, c, c.listGroups)
+		if err != nil {
+			return nil, err
+		}
+
+		list = filterObjects(c.filters.Groups, list)
+		index := make(map[string]*v2.ResourceId, len(list))
+		for _, group := range list {
+			index[group.Name] = c.scopeID(&v2.ResourceId{ResourceType: groupResourceTypeID, Resource: string(group.UID)})
+		}
+		c.indexVirtualGroups(index)
+
+		return index, nil
+	})
+}
+
+// syncedNamespaces returns the set of the synced namespaces, it is
+// reused until stale.
+func (c *Client) syncedNamespaces(ctx context.Context) (map[string]bool, error) {
+	return c.synced.get(c.staleAfter(), func() (map[string]bool, error) {
+		names, err := c.namespaceNames(ctx)
+		if err != nil {
+			return nil, err
+		}
+		synced := make(map[string]bool, len(names))
+		for _, name := range names {
+			synced[name] = true
+		}
+		return synced, nil
+	})
+}
+
+// serviceAccountIndex maps the name of the service accounts of a
+// namespace to their resource ID, it is reused until stale.
+func (c *Client) serviceAccountIndex(ctx context.Context, namespace string) (map[string]*v2.ResourceId, error) {
+	return c.serviceAccounts.get(namespace, c.staleAfter(), func() (map[string]*v2.ResourceId, error) {
+		list, err := listAll(ctx, c, c.listServiceAccounts(namespace))
+		if err != nil {
+			return nil, err
+		}
+
+		list = filterObjects(c.filters.ServiceAccounts, list)
+		index := make(map[string]*v2.ResourceId, len(list))
+		for _, sa := range list {
+			index[sa.Name] = c.scopeID(&v2.ResourceId{ResourceType: serviceAccountResourceTypeID, Resource: string(sa.UID)})
+		}
+
+		return index, nil
+	})
+}
+
+// resolve returns the principal of a subject, if it was synced.
+func (s *subjectIndex) resolve(subject rbacv1.Subject) (*v2.ResourceId, bool) {
+	var id *v2.ResourceId
+	switch subject.Kind {
+	case rbacv1.UserKind:
+		id = s.users[subject.Name]
+	case rbacv1.GroupKind:
+		id = s.groups[subject.Name]
+	case rbacv1.ServiceAccountKind:
+		id = s.serviceAccounts[subject.Namespace][subject.Name]
+	}
+
+	return id, id != nil
+}
+
+func namespacedName(meta metav1.ObjectMeta) string {
+	return meta.Namespace + "/" + meta.Name
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	userapiv1 "github.com/openshift/api/user/v1"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// servedClient is a client of a cluster known to serve `gvs`.
+func servedClient(gvs ...schema.GroupVersion) *Client {
+	c := &Client{defaultPageSize: DefaultPageSize, served: map[schema.GroupVersion]bool{}}
+	for _, gv := range gvs {
+		c.served[gv] = true
+	}
+	return c
+}
+
+func TestSubjectIndexFor(t *testing.T) {
+	sa := func(namespace, name string) rbacv1.Subject {
+		return rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: namespace, Name: name}
+	}
+	alice := rbacv1.Subject{Kind: rbacv1.UserKind, Name: "alice"}
+	admins := rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "admins"}
+
+	tests := []struct {
+		name     string
+		subjects []rbacv1.Subject
+		resolve  rbacv1.Subject
+		want     *v2.ResourceId
+		// wantLists are the listings made by both calls
+		wantLists int
+	}{
+		{
+			name:      "user",
+			subjects:  []rbacv1.Subject{alice},
+			resolve:   alice,
+			want:      &v2.ResourceId{ResourceType: userResourceTypeID, Resource: "alice-uid"},
+			wantLists: 1,
+		},
+		{
+			name:      "group",
+			subjects:  []rbacv1.Subject{admins},
+			resolve:   admins,
+			want:      &v2.ResourceId{ResourceType: groupResourceTypeID, Resource: "admins-uid"},
+			wantLists: 1,
+		},
+		{
+			name:      "service account",
+			subjects:  []rbacv1.Subject{sa("team-a", "deployer"), sa("team-a", "builder")},
+			resolve:   sa("team-a", "deployer"),
+			want:      &v2.ResourceId{ResourceType: serviceAccountResourceTypeID, Resource: "deployer-uid"},
+			wantLists: 1,
+		},
+		{
+			name:      "service account of another namespace",
+			subjects:  []rbacv1.Subject{sa("team-a", "deployer"), sa("team-b", "deployer")},
+			resolve:   sa("team-b", "deployer"),
+			wantLists: 2,
+		},
+		{
+			// e.g. the service accounts of `openshift-*` namespaces bound by
+			// cluster role bindings, they may not be listed either
+			name:      "service account of a namespace that isn't synced",
+			subjects:  []rbacv1.Subject{sa("openshift-monitoring", "prometheus-k8s")},
+			resolve:   sa("openshift-monitoring", "prometheus-k8s"),
+			wantLists: 0,
+		},
+		{
+			name:      "service account of a filtered out namespace",
+			subjects:  []rbacv1.Subject{sa("kube-system", "deployer")},
+			resolve:   sa("kube-system", "deployer"),
+			wantLists: 0,
+		},
+		{
+			name:      "unknown user",
+			subjects:  []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "bob"}},
+			resolve:   rbacv1.Subject{Kind: rbacv1.UserKind, Name: "bob"},
+			wantLists: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := newMetadataFake(
+				partialObject(usersResource, "User", "", "alice", nil),
+				partialObject(serviceAccountsResource, "ServiceAccount", "team-a", "deployer", nil),
+			)
+			users := userfake.NewSimpleClientset(&userapiv1.Group{
+				ObjectMeta: metav1.ObjectMeta{Name: "admins", UID: "admins-uid"},
+			})
+			excludeKube, err := NewFilter(nil, OpenShiftExcludedNamespaces)
+			require.NoError(t, err)
+			c := servedClient(userapiv1.GroupVersion)
+			c.metadataClient, c.usersClient = meta, users.UserV1()
+			c.namespaces, c.filters = []string{"team-a", "team-b", "kube-system"}, Filters{Namespaces: excludeKube}
+
+			for range 2 {
+				index, err := c.subjectIndexFor(context.Background(), tt.subjects)
+				require.NoError(t, err)
+				id, ok := index.resolve(tt.resolve)
+				require.Equal(t, tt.want != nil, ok)
+				require.Equal(t, tt.want, id)
+			}
+			// the listings are indexed once per sync
+			require.Len(t, append(meta.Actions(), users.Actions()...), tt.wantLists)
+		})
+	}
+}
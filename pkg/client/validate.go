@@ -0,0 +1,200 @@
+package client
+
+// validate.go checks, before syncing, that the API server can be
+// reached with the credentials of the client and that they hold every
+// permission the connector needs, so a missing permission is reported
+// up front rather than halfway through a sync.
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// permission is an access to the API server needed by the connector.
+type permission struct {
+	verb     string
+	resource schema.GroupVersionResource
+	// namespace is empty for cluster scoped resources, and for
+	// namespaced resources of every namespace.
+	namespace string
+}
+
+func (p permission) String() string {
+	s := p.verb + " " + p.resource.Resource
+	if p.resource.Group != "" {
+		s += "." + p.resource.Group
+	}
+	if p.namespace != "" {
+		s += " in namespace " + p.namespace
+	}
+	return s
+}
+
+// MissingPermissionsError lists the permissions the credentials of the
+// client lack.
+type MissingPermissionsError struct {
+	Permissions []string
+}
+
+func (e *MissingPermissionsError) Error() string {
+	return "missing permissions: " + strings.Join(e.Permissions, ", ")
+}
+
+// requiredPermissions lists the permissions needed by the enabled
+// features of the client. Without the user API, users and groups are
+// derived from the role bindings and cluster role bindings. Without the
+// security API, there are no security context constraints to sync, and
+// without the OAuth API no access tokens to read the logins from.
+func (c *Client) requiredPermissions(userAPI, sccAPI, oauthAPI bool) []permission {
+	// the informers of the cache watch what they list
+	verbs := []string{"list"}
+	if c.useCache {
+		verbs = append(verbs, "watch")
+	}
+
+	var perms []permission
+	add := func(resource schema.GroupVersionResource, namespace string, verbs ...string) {
+		for _, verb := range verbs {
+			perms = append(perms, permission{verb: verb, resource: resource, namespace: namespace})
+		}
+	}
+
+	if userAPI {
+		add(usersResource, "", verbs...)
+		add(groupsResource, "", verbs...)
+		if !c.useCache {
+			add(groupsResource, "", "get")
+		}
+	}
+	add(clusterRolesResource, "", verbs...)
+	add(clusterRoleBindingsResource, "", verbs...)
+	if sccAPI {
+		add(sccResource, "", "list", "get")
+	}
+	if userAPI && oauthAPI {
+		add(oauthClientsResource, "", "list")
+		// revoking an authorization deletes it
+		add(oauthClientAuthorizationsResource, "", "list", "get", "delete")
+		if c.lastLogin {
+			add(oauthAccessTokensResource, "", "list")
+		}
+	}
+
+	namespaces := slices.Clone(c.namespaces)
+	if slices.Contains(namespaces, AllNamespaces) {
+		add(namespacesResource, "", verbs...)
+		namespaces = []string{""}
+	}
+	slices.Sort(namespaces)
+	for _, namespace := range slices.Compact(namespaces) {
+		add(rolesResource, namespace, verbs...)
+		add(roleBindingsResource, namespace, verbs...)
+		add(serviceAccountsResource, namespace, verbs...)
+	}
+
+	return perms
+}
+
+// Validate checks that the API server can be reached, whether it
+// serves the user API of OpenShift and that the credentials of the
+// client hold every permission needed. The missing permissions are
+// returned all at once as a MissingPermissionsError.
+func (c *Client) Validate(ctx context.Context) error {
+	if c.impersonate.UserName != "" {
+		if err := c.validateImpersonation(ctx); err != nil {
+			return err
+		}
+	}
+
+	userAPI, err := c.hasUserAPI(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to reach the API server, error: %w", err)
+	}
+	sccAPI, err := c.hasSCCAPI(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to reach the API server, error: %w", err)
+	}
+	oauthAPI, err := c.hasOAuthAPI(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to reach the API server, error: %w", err)
+	}
+
+	var missing []string
+	for _, perm := range c.requiredPermissions(userAPI, sccAPI, oauthAPI) {
+		allowed, err := c.allowed(ctx, perm)
+		if err != nil {
+			return fmt.Errorf("unable to review permission (%s), error: %w", perm, err)
+		}
+		if !allowed {
+			missing = append(missing, perm.String())
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingPermissionsError{Permissions: missing}
+	}
+
+	return nil
+}
+
+// validateImpersonation checks that the credentials of the client can
+// impersonate the configured user and groups, and that the API server
+// sees the requests as made by them.
+func (c *Client) validateImpersonation(ctx context.Context) error {
+	user := c.impersonate.UserName
+	review, err := withRetry(ctx, func() (*authenticationv1.SelfSubjectReview, error) {
+		return c.k8sClient.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{})
+	})
+	switch {
+	case apierrors.IsForbidden(err):
+		return fmt.Errorf("unable to impersonate user %s, the credentials need the impersonate permission on it and its groups, error: %w", user, err)
+	case apierrors.IsNotFound(err):
+		// SelfSubjectReview is served since Kubernetes 1.28, without it
+		// a failed impersonation is caught by the next requests anyway
+		return nil
+	case err != nil:
+		return fmt.Errorf("unable to review the impersonated user, error: %w", err)
+	}
+
+	info := review.Status.UserInfo
+	if info.Username != user {
+		return fmt.Errorf("requests are made as %s instead of the impersonated user %s", info.Username, user)
+	}
+	for _, group := range c.impersonate.Groups {
+		if !slices.Contains(info.Groups, group) {
+			return fmt.Errorf("requests are made without the impersonated group %s", group)
+		}
+	}
+
+	return nil
+}
+
+// allowed asks the API server whether the credentials of the client
+// hold a permission.
+func (c *Client) allowed(ctx context.Context, perm permission) (bool, error) {
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: perm.namespace,
+				Verb:      perm.verb,
+				Group:     perm.resource.Group,
+				Resource:  perm.resource.Resource,
+			},
+		},
+	}
+	result, err := withRetry(ctx, func() (*authorizationv1.SelfSubjectAccessReview, error) {
+		return c.k8sClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.Status.Allowed, nil
+}
@@ -0,0 +1,197 @@
+package client
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	oauthv1 "github.com/openshift/api/oauth/v1"
+	securityv1 "github.com/openshift/api/security/v1"
+	v1 "github.com/openshift/api/user/v1"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestValidate(t *testing.T) {
+	openShift := []schema.GroupVersion{v1.GroupVersion, securityv1.GroupVersion, oauthv1.GroupVersion}
+	tests := []struct {
+		name       string
+		served     []schema.GroupVersion
+		namespaces []string
+		useCache   bool
+		lastLogin  bool
+		// denied are the permissions the credentials lack
+		denied []string
+		// wantReviews are the permissions reviewed, in order
+		wantReviews []string
+		wantMissing []string
+	}{
+		{
+			name:       "OpenShift",
+			served:     openShift,
+			namespaces: []string{"team-b", "team-a", "team-b"},
+			wantReviews: []string{
+				"list users.user.openshift.io",
+				"list groups.user.openshift.io",
+				"get groups.user.openshift.io",
+				"list clusterroles.rbac.authorization.k8s.io",
+				"list clusterrolebindings.rbac.authorization.k8s.io",
+				"list securitycontextconstraints.security.openshift.io",
+				"get securitycontextconstraints.security.openshift.io",
+				"list oauthclients.oauth.openshift.io",
+				"list oauthclientauthorizations.oauth.openshift.io",
+				"get oauthclientauthorizations.oauth.openshift.io",
+				"delete oauthclientauthorizations.oauth.openshift.io",
+				"list roles.rbac.authorization.k8s.io in namespace team-a",
+				"list rolebindings.rbac.authorization.k8s.io in namespace team-a",
+				"list serviceaccounts in namespace team-a",
+				"list roles.rbac.authorization.k8s.io in namespace team-b",
+				"list rolebindings.rbac.authorization.k8s.io in namespace team-b",
+				"list serviceaccounts in namespace team-b",
+			},
+		},
+		{
+			name:       "user API missing",
+			namespaces: []string{"team-a"},
+			wantReviews: []string{
+				"list clusterroles.rbac.authorization.k8s.io",
+				"list clusterrolebindings.rbac.authorization.k8s.io",
+				"list roles.rbac.authorization.k8s.io in namespace team-a",
+				"list rolebindings.rbac.authorization.k8s.io in namespace team-a",
+				"list serviceaccounts in namespace team-a",
+			},
+		},
+		{
+			// the OAuth clients and tokens belong to the users
+			name:       "user API missing with the OAuth API",
+			served:     []schema.GroupVersion{oauthv1.GroupVersion},
+			namespaces: []string{"team-a"},
+			lastLogin:  true,
+			wantReviews: []string{
+				"list clusterroles.rbac.authorization.k8s.io",
+				"list clusterrolebindings.rbac.authorization.k8s.io",
+				"list roles.rbac.authorization.k8s.io in namespace team-a",
+				"list rolebindings.rbac.authorization.k8s.io in namespace team-a",
+				"list serviceaccounts in namespace team-a",
+			},
+		},
+		{
+			name:       "all namespaces",
+			namespaces: []string{"team-a", AllNamespaces},
+			wantReviews: []string{
+				"list clusterroles.rbac.authorization.k8s.io",
+				"list clusterrolebindings.rbac.authorization.k8s.io",
+				"list namespaces",
+				"list roles.rbac.authorization.k8s.io",
+				"list rolebindings.rbac.authorization.k8s.io",
+				"list serviceaccounts",
+			},
+		},
+		{
+			name:       "informer cache",
+			served:     []schema.GroupVersion{v1.GroupVersion},
+			namespaces: []string{AllNamespaces},
+			useCache:   true,
+			wantReviews: []string{
+				"list users.user.openshift.io",
+				"watch users.user.openshift.io",
+				"list groups.user.openshift.io",
+				"watch groups.user.openshift.io",
+				"list clusterroles.rbac.authorization.k8s.io",
+				"watch clusterroles.rbac.authorization.k8s.io",
+				"list clusterrolebindings.rbac.authorization.k8s.io",
+				"watch clusterrolebindings.rbac.authorization.k8s.io",
+				"list namespaces",
+				"watch namespaces",
+				"list roles.rbac.authorization.k8s.io",
+				"watch roles.rbac.authorization.k8s.io",
+				"list rolebindings.rbac.authorization.k8s.io",
+				"watch rolebindings.rbac.authorization.k8s.io",
+				"list serviceaccounts",
+				"watch serviceaccounts",
+			},
+		},
+		{
+			name:       "last login",
+			served:     []schema.GroupVersion{v1.GroupVersion, oauthv1.GroupVersion},
+			namespaces: []string{"team-a"},
+			lastLogin:  true,
+			wantReviews: []string{
+				"list users.user.openshift.io",
+				"list groups.user.openshift.io",
+				"get groups.user.openshift.io",
+				"list clusterroles.rbac.authorization.k8s.io",
+				"list clusterrolebindings.rbac.authorization.k8s.io",
+				"list oauthclients.oauth.openshift.io",
+				"list oauthclientauthorizations.oauth.openshift.io",
+				"get oauthclientauthorizations.oauth.openshift.io",
+				"delete oauthclientauthorizations.oauth.openshift.io",
+				"list oauthaccesstokens.oauth.openshift.io",
+				"list roles.rbac.authorization.k8s.io in namespace team-a",
+				"list rolebindings.rbac.authorization.k8s.io in namespace team-a",
+				"list serviceaccounts in namespace team-a",
+			},
+		},
+		{
+			name:       "missing permissions",
+			namespaces: []string{"team-a"},
+			denied: []string{
+				"list clusterroles.rbac.authorization.k8s.io",
+				"list serviceaccounts in namespace team-a",
+			},
+			wantReviews: []string{
+				"list clusterroles.rbac.authorization.k8s.io",
+				"list clusterrolebindings.rbac.authorization.k8s.io",
+				"list roles.rbac.authorization.k8s.io in namespace team-a",
+				"list rolebindings.rbac.authorization.k8s.io in namespace team-a",
+				"list serviceaccounts in namespace team-a",
+			},
+			wantMissing: []string{
+				"list clusterroles.rbac.authorization.k8s.io",
+				"list serviceaccounts in namespace team-a",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8s := k8sfake.NewSimpleClientset()
+			for _, gv := range tt.served {
+				k8s.Resources = append(k8s.Resources, &metav1.APIResourceList{GroupVersion: gv.String()})
+			}
+			var reviews []string
+			k8s.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+				attrs := review.Spec.ResourceAttributes
+				perm := permission{
+					verb:      attrs.Verb,
+					resource:  schema.GroupVersionResource{Group: attrs.Group, Resource: attrs.Resource},
+					namespace: attrs.Namespace,
+				}.String()
+				reviews = append(reviews, perm)
+				review.Status.Allowed = !slices.Contains(tt.denied, perm)
+				return true, review, nil
+			})
+			c := &Client{
+				k8sClient:  k8s,
+				namespaces: tt.namespaces,
+				useCache:   tt.useCache,
+				lastLogin:  tt.lastLogin,
+			}
+
+			err := c.Validate(context.Background())
+			require.Equal(t, tt.wantReviews, reviews)
+			if tt.wantMissing != nil {
+				var missing *MissingPermissionsError
+				require.ErrorAs(t, err, &missing)
+				require.Equal(t, tt.wantMissing, missing.Permissions)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
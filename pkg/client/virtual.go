@@ -0,0 +1,172 @@
+package client
+
+// virtual.go synthesizes the groups that have no Group object but that
+// users are implicitly members of, OpenShift grants them powerful
+// defaults (e.g. self-provisioner to system:authenticated:oauth), so
+// their bindings are access everyone gets.
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+const (
+	// groupAuthenticated has every authenticated user, service
+	// accounts included.
+	groupAuthenticated = "system:authenticated"
+	// groupAuthenticatedOAuth has the users authenticated by the
+	// OAuth server of OpenShift, those with a User object.
+	groupAuthenticatedOAuth = "system:authenticated:oauth"
+	// groupUnauthenticated has the anonymous requests, no user.
+	groupUnauthenticated = "system:unauthenticated"
+)
+
+var virtualGroups = []string{groupAuthenticated, groupAuthenticatedOAuth, groupUnauthenticated}
+
+// isVirtualGroup reports whether a resource is one of the virtual groups.
+func (c *Client) isVirtualGroup(group *v2.Resource) bool {
+	return slices.Contains(virtualGroups, group.DisplayName) &&
+		group.Id.Resource == c.scopeID(externalGroupResourceID(group.DisplayName)).Resource
+}
+
+// listVirtualGroups list the virtual groups that aren't filtered out.
+func (c *Client) listVirtualGroups() ([]*v2.Resource, error) {
+	var groups []*v2.Resource
+	for _, name := range virtualGroups {
+		if !c.filters.Groups.Match(name, nil) {
+			continue
+		}
+		group, err := convertVirtualGroup2Resource(name)
+		if err != nil {
+			return nil, fmt.Errorf("virtual group %s, error: %w", name, err)
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+// indexVirtualGroups adds the virtual groups to an index of groups.
+func (c *Client) indexVirtualGroups(index map[string]*v2.ResourceId) {
+	for _, name := range virtualGroups {
+		if c.filters.Groups.Match(name, nil) {
+			index[name] = c.scopeID(externalGroupResourceID(name))
+		}
+	}
+}
+
+// matchUsersToVirtualGroup grants the membership of a virtual group to
+// every synced user it implicitly has.
+func (c *Client) matchUsersToVirtualGroup(ctx context.Context, entitlement *v2.Resource) ([]*v2.Grant, error) {
+	userAPI, err := c.hasUserAPI(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var members []*v2.ResourceId
+	switch entitlement.DisplayName {
+	case groupAuthenticated:
+		members, err = c.syncedUsers(ctx, userAPI, true)
+		if err == nil {
+			var serviceAccounts []*v2.ResourceId
+			serviceAccounts, err = c.syncedServiceAccounts(ctx)
+			members = append(members, serviceAccounts...)
+		}
+	case groupAuthenticatedOAuth:
+		// without the user API there is no OAuth server either
+		if userAPI {
+			members, err = c.syncedUsers(ctx, userAPI, false)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the members of virtual group %s, error: %w", entitlement.DisplayName, err)
+	}
+
+	gnts := make([]*v2.Grant, 0, len(members))
+	for _, member := range members {
+		gnts = append(gnts, grant.NewGrant(
+			entitlement,
+			"member",
+			member,
+			grant.WithGrantMetadata(map[string]interface{}{"virtual_group": true}),
+		))
+	}
+
+	return gnts, nil
+}
+
+// syncedUsers returns the IDs of the synced users, sorted. The users
+// without a User object (external members of groups, or users derived
+// from role bindings) are only included if `external` is set.
+func (c *Client) syncedUsers(ctx context.Context, userAPI, external bool) ([]*v2.ResourceId, error) {
+	var ids []*v2.ResourceId
+	if !userAPI {
+		names, err := c.subjectNames(ctx, rbacv1.UserKind)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if c.filters.Users.Match(name, nil) {
+				ids = append(ids, c.scopeID(externalUserResourceID(name)))
+			}
+		}
+		return ids, nil
+	}
+
+	index, err := c.userIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range index {
+		ids = append(ids, id)
+	}
+	if external {
+		groups, err := listAll(ctx, c, c.listGroups)
+		if err != nil {
+			return nil, err
+		}
+		seen := map[string]bool{}
+		for _, group := range filterObjects(c.filters.Groups, groups) {
+			for _, member := range group.Users {
+				if _, ok := index[member]; ok || seen[member] || !c.filters.Users.Match(member, nil) {
+					continue
+				}
+				seen[member] = true
+				ids = append(ids, c.scopeID(externalUserResourceID(member)))
+			}
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Resource < ids[j].Resource })
+
+	return ids, nil
+}
+
+// syncedServiceAccounts returns the IDs of the service accounts of the
+// synced namespaces, sorted. They authenticate with their tokens, not
+// through the OAuth server.
+func (c *Client) syncedServiceAccounts(ctx context.Context) ([]*v2.ResourceId, error) {
+	namespaces, err := c.namespaceNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []*v2.ResourceId
+	for _, namespace := range namespaces {
+		index, err := c.serviceAccountIndex(ctx, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list service accounts, namespace %s, error: %w", namespace, err)
+		}
+		for _, id := range index {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Resource < ids[j].Resource })
+
+	return ids, nil
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	userapiv1 "github.com/openshift/api/user/v1"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMatchUsersToVirtualGroup(t *testing.T) {
+	tests := []struct {
+		name  string
+		group string
+		want  []string
+	}{
+		{name: "authenticated", group: groupAuthenticated, want: []string{"alice-uid", "bob", "deployer-uid"}},
+		{name: "authenticated by the OAuth server", group: groupAuthenticatedOAuth, want: []string{"alice-uid"}},
+		{name: "unauthenticated", group: groupUnauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := servedClient(userapiv1.GroupVersion)
+			c.namespaces = []string{"team-a"}
+			c.metadataClient = newMetadataFake(
+				partialObject(usersResource, "User", "", "alice", nil),
+				partialObject(serviceAccountsResource, "ServiceAccount", "team-a", "deployer", nil),
+				partialObject(serviceAccountsResource, "ServiceAccount", "team-b", "builder", nil),
+			)
+			c.usersClient = userfake.NewSimpleClientset(&userapiv1.Group{
+				ObjectMeta: metav1.ObjectMeta{Name: "admins", UID: "admins-uid"},
+				Users:      userapiv1.OptionalNames{"alice", "bob"},
+			}).UserV1()
+			group, err := convertVirtualGroup2Resource(tt.group)
+			require.NoError(t, err)
+
+			gnts, err := c.matchUsersToVirtualGroup(context.Background(), group)
+			require.NoError(t, err)
+			var members []string
+			for _, g := range gnts {
+				members = append(members, g.Principal.Id.Resource)
+			}
+			require.Equal(t, tt.want, members)
+		})
+	}
+}
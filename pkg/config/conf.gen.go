@@ -5,7 +5,43 @@ import "reflect"
 
 type Openshift struct {
 	KubeConfig string `mapstructure:"kube-config"`
+	KubeConfigContent string `mapstructure:"kube-config-content"`
+	KubeContext string `mapstructure:"kube-context"`
+	Clusters []string `mapstructure:"clusters"`
+	ApiServerUrl string `mapstructure:"api-server-url"`
+	BearerToken string `mapstructure:"bearer-token"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	CaCert string `mapstructure:"ca-cert"`
+	TlsCaBundlePath string `mapstructure:"tls-ca-bundle-path"`
+	TlsCaBundle string `mapstructure:"tls-ca-bundle"`
+	TlsServerName string `mapstructure:"tls-server-name"`
+	TlsInsecureSkipVerify bool `mapstructure:"tls-insecure-skip-verify"`
+	ProxyUrl string `mapstructure:"proxy-url"`
+	ImpersonateUser string `mapstructure:"impersonate-user"`
+	ImpersonateGroups []string `mapstructure:"impersonate-groups"`
 	Namespace string `mapstructure:"namespace"`
+	Namespaces []string `mapstructure:"namespaces"`
+	IncludeUsers []string `mapstructure:"include-users"`
+	ExcludeUsers []string `mapstructure:"exclude-users"`
+	IncludeGroups []string `mapstructure:"include-groups"`
+	ExcludeGroups []string `mapstructure:"exclude-groups"`
+	IncludeNamespaces []string `mapstructure:"include-namespaces"`
+	ExcludeNamespaces []string `mapstructure:"exclude-namespaces"`
+	IncludeRoles []string `mapstructure:"include-roles"`
+	ExcludeRoles []string `mapstructure:"exclude-roles"`
+	IncludeServiceAccounts []string `mapstructure:"include-service-accounts"`
+	ExcludeServiceAccounts []string `mapstructure:"exclude-service-accounts"`
+	OpenshiftDefaultFilters bool `mapstructure:"openshift-default-filters"`
+	PermissionEntitlements bool `mapstructure:"permission-entitlements"`
+	SyncLastLogin bool `mapstructure:"sync-last-login"`
+	Concurrency int `mapstructure:"concurrency"`
+	PageSize int `mapstructure:"page-size"`
+	Qps int `mapstructure:"qps"`
+	Burst int `mapstructure:"burst"`
+	RequestTimeout int `mapstructure:"request-timeout"`
+	IndexTtl int `mapstructure:"index-ttl"`
+	InformerCache bool `mapstructure:"informer-cache"`
 }
 
 func (c *Openshift) findFieldByTag(tagValue string) (any, bool) {
@@ -1,11 +1,18 @@
 package config
 
 import (
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/conductorone/baton-sdk/pkg/field"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
 var (
@@ -15,35 +22,423 @@ var (
 		field.WithDescription("Path to kubeconfig file"),
 		field.WithDisplayName("Kube Config"),
 	)
+	KubeConfigContent = field.StringField(
+		"kube-config-content",
+		field.WithIsSecret(true),
+		field.WithDescription("Content (YAML) of a kubeconfig file, used instead of a kubeconfig file on disk"),
+		field.WithDisplayName("Kube Config Content"),
+	)
+	KubeContext = field.StringField(
+		"kube-context",
+		field.WithDescription("Context of the kubeconfig to use, defaults to its current context"),
+		field.WithDisplayName("Kube Context"),
+	)
+	Clusters = field.StringSliceField(
+		"clusters",
+		field.WithDescription("Clusters to sync from the kubeconfig, as name=context entries (a bare context is also the name of its cluster), each context with its own credentials"),
+		field.WithDisplayName("Clusters"),
+	)
+	ApiServerURL = field.StringField(
+		"api-server-url",
+		field.WithDescription("URL of the Kubernetes API server, used with a bearer token instead of a kubeconfig file"),
+		field.WithDisplayName("API Server URL"),
+	)
+	BearerToken = field.StringField(
+		"bearer-token",
+		field.WithIsSecret(true),
+		field.WithDescription("Bearer token (e.g. of a service account) used to authenticate against the API server"),
+		field.WithDisplayName("Bearer Token"),
+	)
+	Username = field.StringField(
+		"username",
+		field.WithDescription("Username to log in the OpenShift OAuth server (e.g. of an htpasswd or LDAP identity provider)"),
+		field.WithDisplayName("Username"),
+	)
+	Password = field.StringField(
+		"password",
+		field.WithIsSecret(true),
+		field.WithDescription("Password to log in the OpenShift OAuth server"),
+		field.WithDisplayName("Password"),
+	)
+	CACert = field.StringField(
+		"ca-cert",
+		field.WithDescription("PEM encoded certificate of the CA that signed the certificate of the API server"),
+		field.WithDisplayName("CA Certificate"),
+	)
+	TLSCABundlePath = field.StringField(
+		"tls-ca-bundle-path",
+		field.WithDescription("Path to a PEM bundle of CAs trusted to sign the certificate of the API server, overrides the kubeconfig"),
+		field.WithDisplayName("TLS CA Bundle Path"),
+	)
+	TLSCABundle = field.StringField(
+		"tls-ca-bundle",
+		field.WithDescription("PEM bundle of CAs trusted to sign the certificate of the API server, overrides the kubeconfig"),
+		field.WithDisplayName("TLS CA Bundle"),
+	)
+	TLSServerName = field.StringField(
+		"tls-server-name",
+		field.WithDescription("Server name used to verify the certificate of the API server, when it differs from the host of its URL"),
+		field.WithDisplayName("TLS Server Name"),
+	)
+	TLSInsecureSkipVerify = field.BoolField(
+		"tls-insecure-skip-verify",
+		field.WithDefaultValue(false),
+		field.WithDescription("Don't verify the certificate of the API server. INSECURE, only meant for testing"),
+		field.WithDisplayName("TLS Insecure Skip Verify"),
+	)
+	ProxyURL = field.StringField(
+		"proxy-url",
+		field.WithDescription("URL of the HTTP(S) proxy used to reach the API server"),
+		field.WithDisplayName("Proxy URL"),
+	)
+	ImpersonateUser = field.StringField(
+		"impersonate-user",
+		field.WithDescription("User to impersonate, the credentials of the connector then only need the impersonate permission"),
+		field.WithDisplayName("Impersonate User"),
+	)
+	ImpersonateGroups = field.StringSliceField(
+		"impersonate-groups",
+		field.WithDescription("Groups of the impersonated user"),
+		field.WithDisplayName("Impersonate Groups"),
+	)
 	Namespace = field.StringField(
 		"namespace",
 		field.WithDefaultValue("default"),
 		field.WithDescription("Kubernetes namespace"),
 		field.WithDisplayName("Namespace"),
 	)
+	Namespaces = field.StringSliceField(
+		"namespaces",
+		field.WithDescription("Kubernetes namespaces to sync, '*' syncs all of them. Takes precedence over --namespace"),
+		field.WithDisplayName("Namespaces"),
+	)
+	IncludeUsers = field.StringSliceField(
+		"include-users",
+		field.WithDescription("Only sync the users matching any of these rules: regular expressions of the name, or label selectors prefixed with 'label:'"),
+		field.WithDisplayName("Include Users"),
+	)
+	ExcludeUsers = field.StringSliceField(
+		"exclude-users",
+		field.WithDescription("Don't sync the users matching any of these rules: regular expressions of the name, or label selectors prefixed with 'label:'"),
+		field.WithDisplayName("Exclude Users"),
+	)
+	IncludeGroups = field.StringSliceField(
+		"include-groups",
+		field.WithDescription("Only sync the groups matching any of these rules: regular expressions of the name, or label selectors prefixed with 'label:'"),
+		field.WithDisplayName("Include Groups"),
+	)
+	ExcludeGroups = field.StringSliceField(
+		"exclude-groups",
+		field.WithDescription("Don't sync the groups matching any of these rules: regular expressions of the name, or label selectors prefixed with 'label:'"),
+		field.WithDisplayName("Exclude Groups"),
+	)
+	IncludeNamespaces = field.StringSliceField(
+		"include-namespaces",
+		field.WithDescription("Only sync the namespaces matching any of these rules: regular expressions of the name, or label selectors prefixed with 'label:'"),
+		field.WithDisplayName("Include Namespaces"),
+	)
+	ExcludeNamespaces = field.StringSliceField(
+		"exclude-namespaces",
+		field.WithDescription("Don't sync the namespaces matching any of these rules: regular expressions of the name, or label selectors prefixed with 'label:'"),
+		field.WithDisplayName("Exclude Namespaces"),
+	)
+	IncludeRoles = field.StringSliceField(
+		"include-roles",
+		field.WithDescription("Only sync the roles matching any of these rules: regular expressions of the name, or label selectors prefixed with 'label:'"),
+		field.WithDisplayName("Include Roles"),
+	)
+	ExcludeRoles = field.StringSliceField(
+		"exclude-roles",
+		field.WithDescription("Don't sync the roles matching any of these rules: regular expressions of the name, or label selectors prefixed with 'label:'"),
+		field.WithDisplayName("Exclude Roles"),
+	)
+	IncludeServiceAccounts = field.StringSliceField(
+		"include-service-accounts",
+		field.WithDescription("Only sync the service accounts matching any of these rules: regular expressions of the name, or label selectors prefixed with 'label:'"),
+		field.WithDisplayName("Include Service Accounts"),
+	)
+	ExcludeServiceAccounts = field.StringSliceField(
+		"exclude-service-accounts",
+		field.WithDescription("Don't sync the service accounts matching any of these rules: regular expressions of the name, or label selectors prefixed with 'label:'"),
+		field.WithDisplayName("Exclude Service Accounts"),
+	)
+	OpenShiftDefaultFilters = field.BoolField(
+		"openshift-default-filters",
+		field.WithDefaultValue(false),
+		field.WithDescription("Exclude the objects OpenShift creates for itself: system users, groups and roles, openshift-* and kube-* namespaces, and builder and deployer service accounts"),
+		field.WithDisplayName("OpenShift Default Filters"),
+	)
+	PermissionEntitlements = field.BoolField(
+		"permission-entitlements",
+		field.WithDefaultValue(false),
+		field.WithDescription("Add an entitlement to roles and cluster roles for each verb and resource their rules allow, with wildcards expanded against the API discovery"),
+		field.WithDisplayName("Permission Entitlements"),
+	)
+	SyncLastLogin = field.BoolField(
+		"sync-last-login",
+		field.WithDefaultValue(false),
+		field.WithDescription("Set the last login and the active sessions of the users from their OAuth access tokens, the tokens themselves are never stored"),
+		field.WithDisplayName("Sync Last Login"),
+	)
+	Concurrency = field.IntField(
+		"concurrency",
+		field.WithDefaultValue(4),
+		field.WithDescription("Maximum number of namespaces listed at the same time"),
+		field.WithDisplayName("Concurrency"),
+	)
+	PageSize = field.IntField(
+		"page-size",
+		field.WithDefaultValue(500),
+		field.WithDescription("Number of objects requested per page when listing from the Kubernetes API"),
+		field.WithDisplayName("Page Size"),
+	)
+	QPS = field.IntField(
+		"qps",
+		field.WithDefaultValue(20),
+		field.WithDescription("Maximum queries per second sent to the Kubernetes API"),
+		field.WithDisplayName("QPS"),
+	)
+	Burst = field.IntField(
+		"burst",
+		field.WithDefaultValue(40),
+		field.WithDescription("Maximum burst of queries sent to the Kubernetes API above the QPS"),
+		field.WithDisplayName("Burst"),
+	)
+	RequestTimeout = field.IntField(
+		"request-timeout",
+		field.WithDefaultValue(60),
+		field.WithDescription("Timeout in seconds of a single request to the Kubernetes API, 0 disables it"),
+		field.WithDisplayName("Request Timeout"),
+	)
+	IndexTTL = field.IntField(
+		"index-ttl",
+		field.WithDefaultValue(300),
+		field.WithDescription("Seconds the indexes of whole listings (e.g. every user by name) are reused while grants are listed, syncs lasting longer list them again"),
+		field.WithDisplayName("Index TTL"),
+	)
+	InformerCache = field.BoolField(
+		"informer-cache",
+		field.WithDefaultValue(false),
+		field.WithDescription("Serve syncs from a cache kept up to date by watches instead of listing the API server on every sync, meant for service mode"),
+		field.WithDisplayName("Informer Cache"),
+	)
 
 	// FieldRelationships defines relationships between the fields.
-	FieldRelationships = []field.SchemaFieldRelationship{}
+	FieldRelationships = []field.SchemaFieldRelationship{
+		field.FieldsDependentOn([]field.SchemaField{CACert}, []field.SchemaField{ApiServerURL}),
+		field.FieldsDependentOn([]field.SchemaField{BearerToken}, []field.SchemaField{ApiServerURL}),
+		field.FieldsRequiredTogether(Username, Password),
+		field.FieldsMutuallyExclusive(BearerToken, Username),
+		field.FieldsMutuallyExclusive(TLSCABundlePath, TLSCABundle),
+		field.FieldsMutuallyExclusive(KubeConfig, KubeConfigContent, ApiServerURL),
+		field.FieldsMutuallyExclusive(KubeContext, ApiServerURL),
+		field.FieldsMutuallyExclusive(Clusters, KubeContext, ApiServerURL),
+		field.FieldsMutuallyExclusive(Clusters, TLSServerName),
+		field.FieldsMutuallyExclusive(Clusters, TLSCABundle),
+		field.FieldsMutuallyExclusive(Clusters, TLSCABundlePath),
+		field.FieldsMutuallyExclusive(Clusters, TLSInsecureSkipVerify),
+		field.FieldsDependentOn([]field.SchemaField{ImpersonateGroups}, []field.SchemaField{ImpersonateUser}),
+	}
 )
 
 //go:generate go run ./gen
 var Configuration = field.NewConfiguration([]field.SchemaField{
 	KubeConfig,
+	KubeConfigContent,
+	KubeContext,
+	Clusters,
+	ApiServerURL,
+	BearerToken,
+	Username,
+	Password,
+	CACert,
+	TLSCABundlePath,
+	TLSCABundle,
+	TLSServerName,
+	TLSInsecureSkipVerify,
+	ProxyURL,
+	ImpersonateUser,
+	ImpersonateGroups,
 	Namespace,
+	Namespaces,
+	IncludeUsers,
+	ExcludeUsers,
+	IncludeGroups,
+	ExcludeGroups,
+	IncludeNamespaces,
+	ExcludeNamespaces,
+	IncludeRoles,
+	ExcludeRoles,
+	IncludeServiceAccounts,
+	ExcludeServiceAccounts,
+	OpenShiftDefaultFilters,
+	PermissionEntitlements,
+	SyncLastLogin,
+	Concurrency,
+	PageSize,
+	QPS,
+	Burst,
+	RequestTimeout,
+	IndexTTL,
+	InformerCache,
 }, field.WithConstraints(FieldRelationships...))
 
 // ValidateConfig is run after the configuration is loaded.
 func ValidateConfig(cfg *Openshift) error {
-	kubeConfigPath := cfg.KubeConfig
-	if kubeConfigPath == "" {
+	if cfg.ApiServerUrl != "" {
+		u, err := url.Parse(cfg.ApiServerUrl)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("api-server-url must be an https URL, got: %s", cfg.ApiServerUrl)
+		}
+		// the certificate isn't verified in insecure mode, so there is
+		// no CA to trust
+		if !cfg.TlsInsecureSkipVerify && !x509.NewCertPool().AppendCertsFromPEM([]byte(cfg.CaCert)) {
+			return errors.New("ca-cert doesn't contain any PEM encoded certificate, it is required with api-server-url")
+		}
+		// without a kubeconfig, these are the only credentials
+		hasToken := cfg.BearerToken != ""
+		hasLogin := cfg.Username != "" && cfg.Password != ""
+		if !hasToken && !hasLogin {
+			return errors.New("api-server-url requires either bearer-token or username and password")
+		}
+	}
+
+	if cfg.TlsInsecureSkipVerify && (cfg.TlsCaBundlePath != "" || cfg.TlsCaBundle != "" || cfg.CaCert != "") {
+		return errors.New("tls-insecure-skip-verify can't be used with a CA bundle or ca-cert")
+	}
+	if cfg.TlsCaBundlePath != "" {
+		if _, err := os.Stat(cfg.TlsCaBundlePath); err != nil {
+			return fmt.Errorf("unable to stat CA bundle file (%s): %w", cfg.TlsCaBundlePath, err)
+		}
+	}
+	if cfg.TlsCaBundle != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(cfg.TlsCaBundle)) {
+		return errors.New("tls-ca-bundle doesn't contain any PEM encoded certificate")
+	}
+	if cfg.ProxyUrl != "" {
+		u, err := url.Parse(cfg.ProxyUrl)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") {
+			return fmt.Errorf("proxy-url must be an http, https or socks5 URL, got: %s", cfg.ProxyUrl)
+		}
+	}
+
+	if cfg.KubeConfig == "" && cfg.KubeConfigContent == "" {
+		if cfg.KubeContext != "" {
+			return errors.New("kube-context requires kube-config or kube-config-content")
+		}
+		if len(cfg.Clusters) > 0 {
+			return errors.New("clusters requires kube-config or kube-config-content")
+		}
 		return nil
 	}
 
-	if _, err := os.Stat(kubeConfigPath); err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return fmt.Errorf("kubeconfig file does not exist: %s", kubeConfigPath)
+	if len(cfg.Clusters) == 0 {
+		_, err := LoadKubeConfig(cfg, "")
+		return err
+	}
+	// each context of the kubeconfig verifies its own API server
+	if cfg.TlsCaBundle != "" || cfg.TlsCaBundlePath != "" || cfg.TlsInsecureSkipVerify || cfg.TlsServerName != "" {
+		return errors.New("clusters can't be used with tls-ca-bundle, tls-ca-bundle-path, tls-insecure-skip-verify or tls-server-name, set the TLS settings of each cluster in the kubeconfig")
+	}
+	clusters, err := ParseClusters(cfg)
+	if err != nil {
+		return err
+	}
+	for _, cluster := range clusters {
+		if _, err := LoadKubeConfig(cfg, cluster.Context); err != nil {
+			return fmt.Errorf("cluster %s: %w", cluster.Name, err)
 		}
-		return fmt.Errorf("unable to stat kubeconfig file (%s): %w", kubeConfigPath, err)
 	}
+
 	return nil
 }
+
+// ClusterContext is a cluster to sync and the context of the
+// kubeconfig used to reach it.
+type ClusterContext struct {
+	Name    string
+	Context string
+}
+
+// ParseClusters parses the `name=context` entries of clusters, a
+// bare context is also the name of its cluster.
+func ParseClusters(cfg *Openshift) ([]ClusterContext, error) {
+	var clusters []ClusterContext
+	seen := map[string]bool{}
+	for _, entry := range cfg.Clusters {
+		name, context, found := strings.Cut(entry, "=")
+		if !found {
+			context = name
+		}
+		name, context = strings.TrimSpace(name), strings.TrimSpace(context)
+		if name == "" || context == "" {
+			return nil, fmt.Errorf("invalid cluster %q, expected name=context", entry)
+		}
+		// the name prefixes the IDs of the resources of the cluster
+		if strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid cluster %q, its name can't contain '/'", entry)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("cluster %s is listed more than once", name)
+		}
+		seen[name] = true
+		clusters = append(clusters, ClusterContext{Name: name, Context: context})
+	}
+
+	return clusters, nil
+}
+
+// LoadKubeConfig loads the kubeconfig, from a file or from its content,
+// and checks that the selected context and its cluster and user exist.
+// The context is `contextName`, kube-context or the current context of
+// the kubeconfig, in that order.
+func LoadKubeConfig(cfg *Openshift, contextName string) (*clientcmdapi.Config, error) {
+	var kubeConfig *clientcmdapi.Config
+	var err error
+	if cfg.KubeConfigContent != "" {
+		kubeConfig, err = clientcmd.Load([]byte(cfg.KubeConfigContent))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse kube-config-content: %w", err)
+		}
+	} else {
+		kubeConfigPath := cfg.KubeConfig
+		if _, err := os.Stat(kubeConfigPath); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("kubeconfig file does not exist: %s", kubeConfigPath)
+			}
+			return nil, fmt.Errorf("unable to stat kubeconfig file (%s): %w", kubeConfigPath, err)
+		}
+		kubeConfig, err = clientcmd.LoadFromFile(kubeConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse kubeconfig file (%s): %w", kubeConfigPath, err)
+		}
+		// the paths of the certificates, keys and token files are
+		// relative to the kubeconfig file, like kubectl reads them
+		if err := clientcmd.ResolveLocalPaths(kubeConfig); err != nil {
+			return nil, fmt.Errorf("unable to resolve the paths of kubeconfig file (%s): %w", kubeConfigPath, err)
+		}
+	}
+
+	if contextName == "" {
+		contextName = cfg.KubeContext
+	}
+	if contextName == "" {
+		contextName = kubeConfig.CurrentContext
+	}
+	if contextName == "" {
+		return nil, errors.New("kubeconfig has no current-context, select one with kube-context")
+	}
+	kubeContext, ok := kubeConfig.Contexts[contextName]
+	if !ok {
+		return nil, fmt.Errorf("context %q not found in kubeconfig, available contexts: %s",
+			contextName, strings.Join(slices.Sorted(maps.Keys(kubeConfig.Contexts)), ", "))
+	}
+	if _, ok := kubeConfig.Clusters[kubeContext.Cluster]; !ok {
+		return nil, fmt.Errorf("cluster %q of context %q not found in kubeconfig", kubeContext.Cluster, contextName)
+	}
+	if _, ok := kubeConfig.AuthInfos[kubeContext.AuthInfo]; !ok {
+		return nil, fmt.Errorf("user %q of context %q not found in kubeconfig", kubeContext.AuthInfo, contextName)
+	}
+	kubeConfig.CurrentContext = contextName
+
+	return kubeConfig, nil
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testCACert is a self-signed PEM encoded CA certificate.
+func testCACert(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestValidateConfigAPIServerURL(t *testing.T) {
+	ca := testCACert(t)
+	tests := []struct {
+		name    string
+		cfg     Openshift
+		wantErr string
+	}{
+		{
+			name: "bearer token",
+			cfg:  Openshift{ApiServerUrl: "https://api.example.com:6443", CaCert: ca, BearerToken: "token"},
+		},
+		{
+			name: "username and password",
+			cfg:  Openshift{ApiServerUrl: "https://api.example.com:6443", CaCert: ca, Username: "alice", Password: "secret"},
+		},
+		{
+			name:    "no credentials",
+			cfg:     Openshift{ApiServerUrl: "https://api.example.com:6443", CaCert: ca},
+			wantErr: "requires either bearer-token or username and password",
+		},
+		{
+			name:    "username without password",
+			cfg:     Openshift{ApiServerUrl: "https://api.example.com:6443", CaCert: ca, Username: "alice"},
+			wantErr: "requires either bearer-token or username and password",
+		},
+		{
+			name:    "plain http",
+			cfg:     Openshift{ApiServerUrl: "http://api.example.com:6443", CaCert: ca, BearerToken: "token"},
+			wantErr: "must be an https URL",
+		},
+		{
+			name:    "invalid CA certificate",
+			cfg:     Openshift{ApiServerUrl: "https://api.example.com:6443", CaCert: "not a certificate", BearerToken: "token"},
+			wantErr: "ca-cert doesn't contain any PEM encoded certificate",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConfig(&tt.cfg)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestValidateConfigInsecure(t *testing.T) {
+	ca := testCACert(t)
+	tests := []struct {
+		name    string
+		cfg     Openshift
+		wantErr string
+	}{
+		{
+			name: "api-server-url without ca-cert",
+			cfg:  Openshift{ApiServerUrl: "https://api.example.com:6443", BearerToken: "token", TlsInsecureSkipVerify: true},
+		},
+		{
+			name:    "api-server-url with ca-cert",
+			cfg:     Openshift{ApiServerUrl: "https://api.example.com:6443", CaCert: ca, BearerToken: "token", TlsInsecureSkipVerify: true},
+			wantErr: "can't be used with a CA bundle or ca-cert",
+		},
+		{
+			name:    "CA bundle",
+			cfg:     Openshift{TlsCaBundle: ca, TlsInsecureSkipVerify: true},
+			wantErr: "can't be used with a CA bundle or ca-cert",
+		},
+		{
+			name:    "api-server-url without ca-cert nor insecure mode",
+			cfg:     Openshift{ApiServerUrl: "https://api.example.com:6443", BearerToken: "token"},
+			wantErr: "it is required with api-server-url",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConfig(&tt.cfg)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestValidateConfigClustersTLS(t *testing.T) {
+	kubeConfig := `apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster:
+    server: https://api.prod.example.com:6443
+- name: staging
+  cluster:
+    server: https://api.staging.example.com:6443
+users:
+- name: admin
+  user:
+    token: token
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: admin
+- name: staging
+  context:
+    cluster: staging
+    user: admin
+`
+	clusters := []string{"prod", "staging"}
+	tests := []struct {
+		name    string
+		cfg     Openshift
+		wantErr string
+	}{
+		{
+			name: "clusters",
+			cfg:  Openshift{KubeConfigContent: kubeConfig, Clusters: clusters},
+		},
+		{
+			name: "clusters with a proxy",
+			cfg:  Openshift{KubeConfigContent: kubeConfig, Clusters: clusters, ProxyUrl: "http://proxy:3128"},
+		},
+		{
+			name:    "clusters with a CA bundle",
+			cfg:     Openshift{KubeConfigContent: kubeConfig, Clusters: clusters, TlsCaBundle: testCACert(t)},
+			wantErr: "clusters can't be used with tls-ca-bundle",
+		},
+		{
+			name:    "clusters with a CA bundle path",
+			cfg:     Openshift{KubeConfigContent: kubeConfig, Clusters: clusters, TlsCaBundlePath: os.DevNull},
+			wantErr: "clusters can't be used with tls-ca-bundle",
+		},
+		{
+			name:    "clusters in insecure mode",
+			cfg:     Openshift{KubeConfigContent: kubeConfig, Clusters: clusters, TlsInsecureSkipVerify: true},
+			wantErr: "clusters can't be used with tls-ca-bundle",
+		},
+		{
+			name:    "clusters with a server name",
+			cfg:     Openshift{KubeConfigContent: kubeConfig, Clusters: clusters, TlsServerName: "api.internal"},
+			wantErr: "clusters can't be used with tls-ca-bundle",
+		},
+		{
+			name: "single cluster with a CA bundle",
+			cfg:  Openshift{KubeConfigContent: kubeConfig, KubeContext: "prod", TlsCaBundle: testCACert(t)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConfig(&tt.cfg)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestLoadKubeConfigLocalPaths(t *testing.T) {
+	dir := t.TempDir()
+	kubeConfig := `apiVersion: v1
+kind: Config
+current-context: prod
+clusters:
+- name: prod
+  cluster:
+    server: https://api.example.com:6443
+    certificate-authority: ca.crt
+users:
+- name: admin
+  user:
+    client-certificate: certs/admin.crt
+    client-key: /etc/keys/admin.key
+    tokenFile: token
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: admin
+`
+	path := filepath.Join(dir, "kubeconfig")
+	require.NoError(t, os.WriteFile(path, []byte(kubeConfig), 0o600))
+
+	tests := []struct {
+		name string
+		cfg  Openshift
+		// want are the paths of the CA, client certificate, client key
+		// and token file
+		want []string
+	}{
+		{
+			name: "file",
+			cfg:  Openshift{KubeConfig: path},
+			want: []string{
+				filepath.Join(dir, "ca.crt"),
+				filepath.Join(dir, "certs/admin.crt"),
+				"/etc/keys/admin.key",
+				filepath.Join(dir, "token"),
+			},
+		},
+		{
+			// there is no file to resolve the paths against
+			name: "content",
+			cfg:  Openshift{KubeConfigContent: kubeConfig},
+			want: []string{"ca.crt", "certs/admin.crt", "/etc/keys/admin.key", "token"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded, err := LoadKubeConfig(&tt.cfg, "")
+			require.NoError(t, err)
+			user := loaded.AuthInfos["admin"]
+			require.Equal(t, tt.want, []string{
+				loaded.Clusters["prod"].CertificateAuthority,
+				user.ClientCertificate,
+				user.ClientKey,
+				user.TokenFile,
+			})
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-openshift/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+)
+
+type clusterRoleBuilder struct {
+	clusters *clusterSet
+}
+
+func (o *clusterRoleBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return clusterRoleResourceType
+}
+
+func (o *clusterRoleBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	clt, err := o.clusters.clientFor(ctx, parentResourceID)
+	if clt == nil || err != nil {
+		return nil, "", nil, err
+	}
+	rsc, next, err := clt.ListClusterRoles(ctx, pToken)
+	if err != nil {
+		return nil, "", nil, o.clusters.failed(ctx, parentResourceID, err)
+	}
+	return rsc, next, nil, nil
+}
+
+func (o *clusterRoleBuilder) Entitlements(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	rv := []*v2.Entitlement{
+		ent.NewAssignmentEntitlement(
+			resource,
+			"member",
+			ent.WithGrantableTo(userResourceType, groupResourceType, serviceAccountResourceType, clusterRoleResourceType),
+			ent.WithDisplayName(fmt.Sprintf("%s Cluster Role member", resource.DisplayName)),
+			ent.WithDescription(fmt.Sprintf("Access to %s cluster role in every namespace", resource.DisplayName)),
+		),
+	}
+
+	// role bindings grant the cluster role in their namespace only
+	clt, err := o.clusters.clientFor(ctx, resource.ParentResourceId)
+	if clt == nil || err != nil {
+		return nil, "", nil, err
+	}
+	namespaces, err := clt.ClusterRoleNamespaces(ctx, resource)
+	if err != nil {
+		return nil, "", nil, o.clusters.failed(ctx, resource.ParentResourceId, err)
+	}
+	for _, namespace := range namespaces {
+		rv = append(rv, ent.NewAssignmentEntitlement(
+			resource,
+			client.NamespacedMemberSlug(namespace),
+			ent.WithGrantableTo(userResourceType, groupResourceType, serviceAccountResourceType),
+			ent.WithDisplayName(fmt.Sprintf("%s in project %s", resource.DisplayName, namespace)),
+			ent.WithDescription(fmt.Sprintf("Access to %s cluster role in %s namespace", resource.DisplayName, namespace)),
+		))
+	}
+
+	perms, err := o.clusters.permissionEntitlements(ctx, resource, clusterRoleResourceType)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return append(rv, perms...), "", nil, nil
+}
+
+func (o *clusterRoleBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	clt, err := o.clusters.clientFor(ctx, resource.ParentResourceId)
+	if clt == nil || err != nil {
+		return nil, "", nil, err
+	}
+	grants, next, err := clt.ListClusterRoleBindings(ctx, resource, pToken)
+	if err != nil {
+		return nil, "", nil, o.clusters.failed(ctx, resource.ParentResourceId, err)
+	}
+	if pToken == nil || pToken.Token == "" {
+		perms, err := clt.PermissionGrants(ctx, resource)
+		if err != nil {
+			return nil, "", nil, o.clusters.failed(ctx, resource.ParentResourceId, err)
+		}
+		aggregated, err := clt.AggregationGrants(ctx, resource)
+		if err != nil {
+			return nil, "", nil, o.clusters.failed(ctx, resource.ParentResourceId, err)
+		}
+		grants = append(append(grants, perms...), aggregated...)
+	}
+	return grants, next, nil, nil
+}
+
+func newClusterRoleBuilder(clusters *clusterSet) *clusterRoleBuilder {
+	return &clusterRoleBuilder{
+		clusters: clusters,
+	}
+}
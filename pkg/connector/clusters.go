@@ -0,0 +1,170 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/conductorone/baton-openshift/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"k8s.io/client-go/rest"
+)
+
+// Cluster is an OpenShift cluster synced by the connector.
+type Cluster struct {
+	// Name of the cluster, it is empty when the connector syncs a
+	// single cluster.
+	Name   string
+	Config *rest.Config
+	// Err is why the configuration of the cluster couldn't be built,
+	// the cluster is skipped and the others are synced anyway.
+	Err error
+}
+
+// clusterClient is the client of a cluster, or the reason it couldn't
+// be created.
+type clusterClient struct {
+	name   string
+	host   string
+	client *client.Client
+	err    error
+}
+
+// clusterSet holds the clients of the synced clusters. With a single
+// unnamed cluster its resources are top-level, otherwise they are
+// children of the resource of their cluster.
+type clusterSet struct {
+	clusters []*clusterClient
+}
+
+func (s *clusterSet) multi() bool {
+	return len(s.clusters) != 1 || s.clusters[0].name != ""
+}
+
+// clientFor returns the client of the cluster that parents a resource.
+// It is nil when there is nothing to sync: many clusters are synced and
+// the resource is not under any of them, or its cluster is unreachable.
+func (s *clusterSet) clientFor(ctx context.Context, parentResourceID *v2.ResourceId) (*client.Client, error) {
+	if s == nil || len(s.clusters) == 0 {
+		return nil, nil
+	}
+	if !s.multi() {
+		return s.clusters[0].client, nil
+	}
+	if parentResourceID == nil || parentResourceID.ResourceType != clusterResourceType.Id {
+		return nil, nil
+	}
+
+	for _, cluster := range s.clusters {
+		if cluster.name != parentResourceID.Resource {
+			continue
+		}
+		if cluster.err != nil {
+			ctxzap.Extract(ctx).Warn("skipping cluster, it couldn't be configured", zap.String("cluster", cluster.name), zap.Error(cluster.err))
+			return nil, nil
+		}
+		return cluster.client, nil
+	}
+
+	return nil, fmt.Errorf("unknown cluster %s", parentResourceID.Resource)
+}
+
+// failed reports an error that happened while syncing the cluster of
+// a resource. When many clusters are synced, the error is logged and
+// swallowed so the sync of the other clusters goes on.
+func (s *clusterSet) failed(ctx context.Context, parentResourceID *v2.ResourceId, err error) error {
+	if !s.multi() || parentResourceID == nil {
+		return err
+	}
+	ctxzap.Extract(ctx).Error("unable to sync cluster, skipping it", zap.String("cluster", parentResourceID.Resource), zap.Error(err))
+	return nil
+}
+
+// validate validates the clusters. When many clusters are synced, a
+// cluster that fails is reported and skipped, the validation only
+// fails when all of them do.
+func (s *clusterSet) validate(ctx context.Context) error {
+	if s == nil || len(s.clusters) == 0 {
+		return nil
+	}
+	if !s.multi() {
+		return s.clusters[0].client.Validate(ctx)
+	}
+
+	var errs []error
+	for _, cluster := range s.clusters {
+		err := cluster.err
+		if err == nil {
+			err = cluster.client.Validate(ctx)
+		}
+		if err != nil {
+			ctxzap.Extract(ctx).Error("cluster failed the validation", zap.String("cluster", cluster.name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("cluster %s: %w", cluster.name, err))
+		}
+	}
+	if len(errs) == len(s.clusters) {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+type clusterBuilder struct {
+	clusters *clusterSet
+}
+
+func (o *clusterBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return clusterResourceType
+}
+
+// List returns a resource for each cluster, only when many clusters are synced.
+func (o *clusterBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if o.clusters == nil || !o.clusters.multi() || parentResourceID != nil {
+		return nil, "", nil, nil
+	}
+
+	var rv []*v2.Resource
+	for _, cluster := range o.clusters.clusters {
+		resource, err := rs.NewResource(
+			cluster.name,
+			clusterResourceType,
+			cluster.name,
+			rs.WithDescription(cluster.host),
+			rs.WithAnnotation(
+				&v2.ChildResourceType{ResourceTypeId: userResourceType.Id},
+				&v2.ChildResourceType{ResourceTypeId: groupResourceType.Id},
+				&v2.ChildResourceType{ResourceTypeId: roleResourceType.Id},
+				&v2.ChildResourceType{ResourceTypeId: clusterRoleResourceType.Id},
+				&v2.ChildResourceType{ResourceTypeId: serviceAccountResourceType.Id},
+				&v2.ChildResourceType{ResourceTypeId: namespaceResourceType.Id},
+				&v2.ChildResourceType{ResourceTypeId: sccResourceType.Id},
+				&v2.ChildResourceType{ResourceTypeId: oauthClientResourceType.Id},
+			),
+		)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		rv = append(rv, resource)
+	}
+
+	return rv, "", nil, nil
+}
+
+func (o *clusterBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func (o *clusterBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func newClusterBuilder(clusters *clusterSet) *clusterBuilder {
+	return &clusterBuilder{
+		clusters: clusters,
+	}
+}
@@ -3,25 +3,32 @@ package connector
 import (
 	"context"
 	"io"
+	"slices"
 
 	"github.com/conductorone/baton-openshift/pkg/client"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
-	"k8s.io/client-go/rest"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
 )
 
 type Connector struct {
-	namespace string
-	client    *client.Client
+	clusters *clusterSet
 }
 
 // ResourceSyncers returns a ResourceSyncer for each resource type that should be synced from the upstream service.
 func (d *Connector) ResourceSyncers(ctx context.Context) []connectorbuilder.ResourceSyncer {
 	return []connectorbuilder.ResourceSyncer{
-		newUserBuilder(d.namespace, d.client),
-		newRoleBuilder(d.namespace, d.client),
-		newGroupBuilder(d.namespace, d.client),
+		newClusterBuilder(d.clusters),
+		newUserBuilder(d.clusters),
+		newRoleBuilder(d.clusters),
+		newClusterRoleBuilder(d.clusters),
+		newGroupBuilder(d.clusters),
+		newServiceAccountBuilder(d.clusters),
+		newNamespaceBuilder(d.clusters),
+		newSCCBuilder(d.clusters),
+		newOAuthClientBuilder(d.clusters),
 	}
 }
 
@@ -42,15 +49,30 @@ func (d *Connector) Metadata(ctx context.Context) (*v2.ConnectorMetadata, error)
 // Validate is called to ensure that the connector is properly configured. It should exercise any API credentials
 // to be sure that they are valid.
 func (d *Connector) Validate(ctx context.Context) (annotations.Annotations, error) {
-	return nil, nil
+	return nil, d.clusters.validate(ctx)
 }
 
-// New returns a new instance of the connector.
-func New(ctx context.Context, namespace string, config *rest.Config) (*Connector, error) {
-	client, err := client.New(config)
-	if err != nil {
-		return nil, err
+// New returns a new instance of the connector. When many clusters are
+// given, a cluster that can't be reached is reported and skipped.
+func New(ctx context.Context, clusters []Cluster, opts ...client.Option) (*Connector, error) {
+	set := &clusterSet{}
+	for _, cluster := range clusters {
+		cc := &clusterClient{name: cluster.Name, err: cluster.Err}
+		if cc.err == nil {
+			cc.host = cluster.Config.Host
+			cc.client, cc.err = client.New(ctx, cluster.Config, append(slices.Clone(opts), client.WithCluster(cluster.Name))...)
+		}
+		set.clusters = append(set.clusters, cc)
 	}
 
-	return &Connector{client: client, namespace: namespace}, nil
+	if !set.multi() && set.clusters[0].err != nil {
+		return nil, set.clusters[0].err
+	}
+	for _, cc := range set.clusters {
+		if cc.err != nil {
+			ctxzap.Extract(ctx).Error("unable to configure cluster, it won't be synced", zap.String("cluster", cc.name), zap.Error(cc.err))
+		}
+	}
+
+	return &Connector{clusters: set}, nil
 }
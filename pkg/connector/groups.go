@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/conductorone/baton-openshift/pkg/client"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
@@ -12,8 +11,7 @@ import (
 )
 
 type groupBuilder struct {
-	namespace string
-	client    *client.Client
+	clusters *clusterSet
 }
 
 func (o *groupBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
@@ -21,18 +19,23 @@ func (o *groupBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
 }
 
 func (o *groupBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
-	groups, err := o.client.ListGroups(ctx)
-	if err != nil {
+	clt, err := o.clusters.clientFor(ctx, parentResourceID)
+	if clt == nil || err != nil {
 		return nil, "", nil, err
 	}
-	return groups, "", nil, nil
+	groups, next, err := clt.ListGroups(ctx, pToken)
+	if err != nil {
+		return nil, "", nil, o.clusters.failed(ctx, parentResourceID, err)
+	}
+	return groups, next, nil, nil
 }
 
 func (o *groupBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
 	var rv []*v2.Entitlement
 
 	assigmentOptions := []ent.EntitlementOption{
-		ent.WithGrantableTo(userResourceType),
+		// the virtual groups hold the service accounts too
+		ent.WithGrantableTo(userResourceType, serviceAccountResourceType),
 		ent.WithDisplayName(fmt.Sprintf("%s Team member", resource.DisplayName)),
 		ent.WithDescription(fmt.Sprintf("Access to %s team", resource.DisplayName)),
 	}
@@ -47,21 +50,20 @@ func (o *groupBuilder) Entitlements(_ context.Context, resource *v2.Resource, _
 }
 
 func (o *groupBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
-	users, err := o.client.ListUsers(ctx)
-	if err != nil {
-		return nil, "", nil, fmt.Errorf("unable to list users to match their group membership, error: %w", err)
+	clt, err := o.clusters.clientFor(ctx, resource.ParentResourceId)
+	if clt == nil || err != nil {
+		return nil, "", nil, err
 	}
-
-	grants, err := o.client.MatchUsersToGroup(ctx, resource, users)
+	grants, err := clt.MatchUsersToGroup(ctx, resource)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("unable to match users membership to groups, error: %w", err)
+		err = fmt.Errorf("unable to match users membership to groups, error: %w", err)
+		return nil, "", nil, o.clusters.failed(ctx, resource.ParentResourceId, err)
 	}
 	return grants, "", nil, nil
 }
 
-func newGroupBuilder(namespace string, clt *client.Client) *groupBuilder {
+func newGroupBuilder(clusters *clusterSet) *groupBuilder {
 	return &groupBuilder{
-		namespace: namespace,
-		client:    clt,
+		clusters: clusters,
 	}
 }
@@ -0,0 +1,43 @@
+package connector
+
+import (
+	"context"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+)
+
+type namespaceBuilder struct {
+	clusters *clusterSet
+}
+
+func (o *namespaceBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return namespaceResourceType
+}
+
+func (o *namespaceBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	clt, err := o.clusters.clientFor(ctx, parentResourceID)
+	if clt == nil || err != nil {
+		return nil, "", nil, err
+	}
+	list, next, err := clt.ListNamespaces(ctx, pToken)
+	if err != nil {
+		return nil, "", nil, o.clusters.failed(ctx, parentResourceID, err)
+	}
+	return list, next, nil, nil
+}
+
+func (o *namespaceBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func (o *namespaceBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func newNamespaceBuilder(clusters *clusterSet) *namespaceBuilder {
+	return &namespaceBuilder{
+		clusters: clusters,
+	}
+}
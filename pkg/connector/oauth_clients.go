@@ -0,0 +1,91 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+)
+
+type oauthClientBuilder struct {
+	clusters *clusterSet
+}
+
+func (o *oauthClientBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return oauthClientResourceType
+}
+
+func (o *oauthClientBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	clt, err := o.clusters.clientFor(ctx, parentResourceID)
+	if clt == nil || err != nil {
+		return nil, "", nil, err
+	}
+	rsc, next, err := clt.ListOAuthClients(ctx, pToken)
+	if err != nil {
+		return nil, "", nil, o.clusters.failed(ctx, parentResourceID, err)
+	}
+	return rsc, next, nil, nil
+}
+
+func (o *oauthClientBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	rv := []*v2.Entitlement{
+		ent.NewPermissionEntitlement(
+			resource,
+			"authorized",
+			ent.WithGrantableTo(userResourceType),
+			ent.WithDisplayName(fmt.Sprintf("Authorized %s OAuth client", resource.DisplayName)),
+			ent.WithDescription(fmt.Sprintf("Let the %s OAuth client act on behalf of the user, within the authorized scopes", resource.DisplayName)),
+		),
+	}
+
+	return rv, "", nil, nil
+}
+
+func (o *oauthClientBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	clt, err := o.clusters.clientFor(ctx, resource.ParentResourceId)
+	if clt == nil || err != nil {
+		return nil, "", nil, err
+	}
+	grants, next, err := clt.ListOAuthClientAuthorizations(ctx, resource, pToken)
+	if err != nil {
+		return nil, "", nil, o.clusters.failed(ctx, resource.ParentResourceId, err)
+	}
+	return grants, next, nil, nil
+}
+
+// Grant is refused, only the users themselves authorize OAuth clients
+// when the clients ask them to.
+func (o *oauthClientBuilder) Grant(_ context.Context, _ *v2.Resource, _ *v2.Entitlement) (annotations.Annotations, error) {
+	return nil, errors.New("OAuth clients are only authorized by the users themselves")
+}
+
+// Revoke deletes the authorization of the OAuth client by the user.
+func (o *oauthClientBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	resource := grant.Entitlement.Resource
+	clt, err := o.clusters.clientFor(ctx, resource.ParentResourceId)
+	if err != nil {
+		return nil, err
+	}
+	if clt == nil {
+		return nil, fmt.Errorf("no cluster to revoke the authorization of %s from", resource.DisplayName)
+	}
+
+	deleted, err := clt.DeleteOAuthClientAuthorization(ctx, resource, grant.Principal.Id)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+	}
+	return nil, nil
+}
+
+func newOAuthClientBuilder(clusters *clusterSet) *oauthClientBuilder {
+	return &oauthClientBuilder{
+		clusters: clusters,
+	}
+}
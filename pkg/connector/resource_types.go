@@ -4,6 +4,13 @@ import (
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 )
 
+// The cluster resource type is for the synced clusters, only when the
+// connector syncs many of them. It parents the resources of its cluster.
+var clusterResourceType = &v2.ResourceType{
+	Id:          "cluster",
+	DisplayName: "Cluster",
+}
+
 // The user resource type is for all user objects from Openshift.
 var userResourceType = &v2.ResourceType{
 	Id:          "user",
@@ -24,3 +31,41 @@ var roleResourceType = &v2.ResourceType{
 	DisplayName: "Role",
 	Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_ROLE},
 }
+
+// The cluster role resource type is for all cluster role objects, granted
+// cluster-wide by cluster role bindings.
+var clusterRoleResourceType = &v2.ResourceType{
+	Id:          "cluster_role",
+	DisplayName: "Cluster Role",
+	Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_ROLE},
+}
+
+// The service account resource type is for all service account objects
+// of the synced namespaces.
+var serviceAccountResourceType = &v2.ResourceType{
+	Id:          "service_account",
+	DisplayName: "Service Account",
+	Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_USER},
+}
+
+// The namespace resource type is for the synced namespaces.
+var namespaceResourceType = &v2.ResourceType{
+	Id:          "namespace",
+	DisplayName: "Namespace",
+}
+
+// The SCC resource type is for the SecurityContextConstraints of
+// OpenShift, their `use` entitlement is who may run pods with them.
+var sccResourceType = &v2.ResourceType{
+	Id:          "scc",
+	DisplayName: "Security Context Constraints",
+}
+
+// The OAuth client resource type is for the OAuth clients of OpenShift,
+// their `authorized` entitlement is held by the users that authorized
+// them to act on their behalf.
+var oauthClientResourceType = &v2.ResourceType{
+	Id:          "oauth_client",
+	DisplayName: "OAuth Client",
+	Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_APP},
+}
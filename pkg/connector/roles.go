@@ -4,16 +4,15 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/conductorone/baton-openshift/pkg/client"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
 	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
 )
 
 type roleBuilder struct {
-	namespace string
-	client    *client.Client
+	clusters *clusterSet
 }
 
 func (o *roleBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
@@ -21,22 +20,32 @@ func (o *roleBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
 }
 
 func (o *roleBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
-	rsc, err := o.client.ListRoles(ctx, o.namespace)
-	if err != nil {
+	clt, err := o.clusters.clientFor(ctx, parentResourceID)
+	if clt == nil || err != nil {
 		return nil, "", nil, err
 	}
-	return rsc, "", nil, nil
+	rsc, next, err := clt.ListRoles(ctx, pToken)
+	if err != nil {
+		return nil, "", nil, o.clusters.failed(ctx, parentResourceID, err)
+	}
+	return rsc, next, nil, nil
 }
 
-func (o *roleBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+func (o *roleBuilder) Entitlements(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
 	var rv []*v2.Entitlement
 
+	trait, err := rs.GetRoleTrait(resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	namespace, _ := rs.GetProfileStringValue(trait.Profile, "namespace")
+
 	// NOTE(shackra): I don't really know what's needed and what
 	// is superflous
 	assigmentOptions := []ent.EntitlementOption{
-		ent.WithGrantableTo(userResourceType),
+		ent.WithGrantableTo(userResourceType, groupResourceType, serviceAccountResourceType),
 		ent.WithDisplayName(fmt.Sprintf("%s Role member", resource.DisplayName)),
-		ent.WithDescription(fmt.Sprintf("Access to %s role in %s namespace", resource.DisplayName, o.namespace)),
+		ent.WithDescription(fmt.Sprintf("Access to %s role in %s namespace", resource.DisplayName, namespace)),
 	}
 
 	rv = append(rv, ent.NewAssignmentEntitlement(
@@ -45,25 +54,62 @@ func (o *roleBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *
 		assigmentOptions...,
 	))
 
-	return rv, "", nil, nil
+	perms, err := o.clusters.permissionEntitlements(ctx, resource, roleResourceType)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return append(rv, perms...), "", nil, nil
 }
 
 func (o *roleBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
-	users, err := o.client.ListUsers(ctx)
+	// NOTE(shackra): resource is a role, not a user!
+	clt, err := o.clusters.clientFor(ctx, resource.ParentResourceId)
+	if clt == nil || err != nil {
+		return nil, "", nil, err
+	}
+	grants, next, err := clt.ListRoleBindings(ctx, resource, pToken)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("unable to list users to match their permissions, error: %w", err)
+		return nil, "", nil, o.clusters.failed(ctx, resource.ParentResourceId, err)
 	}
-	// NOTE(shackra): resource is a role, not a user!
-	grants, err := o.client.ListRoleBindings(ctx, o.namespace, resource, users)
+	if pToken == nil || pToken.Token == "" {
+		perms, err := clt.PermissionGrants(ctx, resource)
+		if err != nil {
+			return nil, "", nil, o.clusters.failed(ctx, resource.ParentResourceId, err)
+		}
+		grants = append(grants, perms...)
+	}
+	return grants, next, nil, nil
+}
+
+// permissionEntitlements lists an entitlement per permission of a role
+// or cluster role, held by the role itself so its members inherit them.
+func (c *clusterSet) permissionEntitlements(ctx context.Context, resource *v2.Resource, roleType *v2.ResourceType) ([]*v2.Entitlement, error) {
+	clt, err := c.clientFor(ctx, resource.ParentResourceId)
+	if clt == nil || err != nil {
+		return nil, err
+	}
+	perms, err := clt.RolePermissions(ctx, resource)
 	if err != nil {
-		return nil, "", nil, err
+		return nil, c.failed(ctx, resource.ParentResourceId, err)
 	}
-	return grants, "", nil, nil
+
+	rv := make([]*v2.Entitlement, 0, len(perms))
+	for _, perm := range perms {
+		rv = append(rv, ent.NewPermissionEntitlement(
+			resource,
+			perm.Name(),
+			ent.WithGrantableTo(roleType),
+			ent.WithDisplayName(fmt.Sprintf("%s: %s", resource.DisplayName, perm.Name())),
+			ent.WithDescription(fmt.Sprintf("Permission to %s, granted by %s", perm.Name(), resource.DisplayName)),
+		))
+	}
+
+	return rv, nil
 }
 
-func newRoleBuilder(namespace string, clt *client.Client) *roleBuilder {
+func newRoleBuilder(clusters *clusterSet) *roleBuilder {
 	return &roleBuilder{
-		namespace: namespace,
-		client:    clt,
+		clusters: clusters,
 	}
 }
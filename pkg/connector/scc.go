@@ -0,0 +1,63 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+)
+
+type sccBuilder struct {
+	clusters *clusterSet
+}
+
+func (o *sccBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return sccResourceType
+}
+
+func (o *sccBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	clt, err := o.clusters.clientFor(ctx, parentResourceID)
+	if clt == nil || err != nil {
+		return nil, "", nil, err
+	}
+	rsc, next, err := clt.ListSCCs(ctx, pToken)
+	if err != nil {
+		return nil, "", nil, o.clusters.failed(ctx, parentResourceID, err)
+	}
+	return rsc, next, nil, nil
+}
+
+func (o *sccBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	rv := []*v2.Entitlement{
+		ent.NewPermissionEntitlement(
+			resource,
+			"use",
+			ent.WithGrantableTo(userResourceType, groupResourceType, serviceAccountResourceType),
+			ent.WithDisplayName(fmt.Sprintf("Use %s SCC", resource.DisplayName)),
+			ent.WithDescription(fmt.Sprintf("Run pods with the %s security context constraints", resource.DisplayName)),
+		),
+	}
+
+	return rv, "", nil, nil
+}
+
+func (o *sccBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	clt, err := o.clusters.clientFor(ctx, resource.ParentResourceId)
+	if clt == nil || err != nil {
+		return nil, "", nil, err
+	}
+	grants, err := clt.ListSCCGrants(ctx, resource)
+	if err != nil {
+		return nil, "", nil, o.clusters.failed(ctx, resource.ParentResourceId, err)
+	}
+	return grants, "", nil, nil
+}
+
+func newSCCBuilder(clusters *clusterSet) *sccBuilder {
+	return &sccBuilder{
+		clusters: clusters,
+	}
+}
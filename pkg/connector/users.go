@@ -3,15 +3,13 @@ package connector
 import (
 	"context"
 
-	"github.com/conductorone/baton-openshift/pkg/client"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
 )
 
 type userBuilder struct {
-	namespace string
-	client    *client.Client
+	clusters *clusterSet
 }
 
 func (o *userBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
@@ -19,11 +17,15 @@ func (o *userBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
 }
 
 func (o *userBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
-	list, err := o.client.ListUsers(ctx)
-	if err != nil {
+	clt, err := o.clusters.clientFor(ctx, parentResourceID)
+	if clt == nil || err != nil {
 		return nil, "", nil, err
 	}
-	return list, "", nil, nil
+	list, next, err := clt.ListUsers(ctx, pToken)
+	if err != nil {
+		return nil, "", nil, o.clusters.failed(ctx, parentResourceID, err)
+	}
+	return list, next, nil, nil
 }
 
 func (o *userBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
@@ -34,9 +36,8 @@ func (o *userBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken
 	return nil, "", nil, nil
 }
 
-func newUserBuilder(namespace string, clt *client.Client) *userBuilder {
+func newUserBuilder(clusters *clusterSet) *userBuilder {
 	return &userBuilder{
-		namespace: namespace,
-		client:    clt,
+		clusters: clusters,
 	}
 }